@@ -0,0 +1,21 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+
+	"github.com/marycka9/go-reverso-api/ankistub"
+	log "github.com/sirupsen/logrus"
+)
+
+// ankistub serves an ankistub.Collection, to try the importer without Anki:
+//
+//	go run ./ankistub/cmd/ankistub -addr localhost:8766
+//	go run ./delivery -anki http://localhost:8766 -french data/french.csv
+func main() {
+	addr := flag.String("addr", "localhost:8766", "Address the stand-in AnkiConnect API listens on")
+	flag.Parse()
+
+	log.Infof("AnkiConnect stand-in listening on http://%s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, ankistub.NewCollection()))
+}
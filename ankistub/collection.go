@@ -0,0 +1,256 @@
+// Package ankistub is an in-memory stand-in for the AnkiConnect API, serving the actions the importer uses, to try
+// and test the importer without Anki
+package ankistub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/atselvan/ankiconnect"
+	log "github.com/sirupsen/logrus"
+)
+
+// note is a note of the collection, with the deck Anki keeps on its cards
+type note struct {
+	id    int64
+	deck  string
+	model string
+	// fields keep the order they were added in, the first one is the one Anki checks duplicates on
+	fields []string
+	values ankiconnect.Fields
+	tags   []string
+}
+
+// Collection is the notes of the stand-in, it serves the AnkiConnect API as an http.Handler
+type Collection struct {
+	mu    sync.Mutex
+	notes map[int64]*note
+	// models are the fields by note type, a note of another type has the fields it is added with
+	models map[string][]string
+	lastID int64
+}
+
+func NewCollection() *Collection {
+	return &Collection{notes: make(map[int64]*note), models: make(map[string][]string)}
+}
+
+// AddModel adds a note type with its fields, the first one is the one Anki checks duplicates on. The fields of the
+// notes of the type that it does not have are dropped
+func (c *Collection) AddModel(name string, fields ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.models[name] = fields
+}
+
+// Len returns the number of notes of the collection
+func (c *Collection) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.notes)
+}
+
+type request struct {
+	Action  string          `json:"action"`
+	Version int             `json:"version"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type response struct {
+	Result any     `json:"result"`
+	Error  *string `json:"error"`
+}
+
+// ServeHTTP runs the action of the request
+func (c *Collection) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	var result any
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err == nil {
+		c.mu.Lock()
+		result, err = c.do(req.Action, req.Params)
+		c.mu.Unlock()
+	}
+
+	res := response{Result: result}
+	if err != nil {
+		message := err.Error()
+		res.Error = &message
+		log.Warnf("%s: %s", req.Action, err)
+	} else {
+		log.Infof("%s: %s", req.Action, req.Params)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// do runs an action, the caller holds the lock
+func (c *Collection) do(action string, params json.RawMessage) (any, error) {
+	switch action {
+	case "version":
+		return 6, nil
+	case ankiconnect.ActionFindNotes:
+		var p ankiconnect.ParamsFindNotes
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		return c.find(p.Query)
+	case ankiconnect.ActionNotesInfo:
+		var p struct {
+			Notes []int64 `json:"notes"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, err
+		}
+		infos := make([]ankiconnect.ResultNotesInfo, 0, len(p.Notes))
+		for _, id := range p.Notes {
+			if n, ok := c.notes[id]; ok {
+				infos = append(infos, n.info())
+			}
+		}
+		return infos, nil
+	case ankiconnect.ActionAddNote:
+		var p ankiconnect.ParamsCreateNote
+		if err := json.Unmarshal(params, &p); err != nil || p.Note == nil {
+			return nil, errors.Join(errors.New("invalid note"), err)
+		}
+		return c.add(*p.Note)
+	case ankiconnect.ActionUpdateNoteFields:
+		var p ankiconnect.ParamsUpdateNote
+		if err := json.Unmarshal(params, &p); err != nil || p.Note == nil {
+			return nil, errors.Join(errors.New("invalid note"), err)
+		}
+		n, ok := c.notes[p.Note.Id]
+		if !ok {
+			return nil, fmt.Errorf("note was not found: %d", p.Note.Id)
+		}
+		for name, value := range p.Note.Fields {
+			if _, ok := n.values[name]; ok {
+				n.values[name] = value
+			}
+		}
+		return nil, nil
+	default:
+		return nil, errors.New("unsupported action")
+	}
+}
+
+func (n *note) info() ankiconnect.ResultNotesInfo {
+	fields := make(map[string]ankiconnect.FieldData, len(n.fields))
+	for i, name := range n.fields {
+		fields[name] = ankiconnect.FieldData{Value: n.values[name], Order: int64(i)}
+	}
+	return ankiconnect.ResultNotesInfo{NoteId: n.id, ModelName: n.model, Fields: fields, Tags: n.tags}
+}
+
+// add adds the note, refusing a duplicate of the first field in the deck and note type as Anki does
+func (c *Collection) add(added ankiconnect.Note) (int64, error) {
+	if added.DeckName == "" || added.ModelName == "" || len(added.Fields) == 0 {
+		return 0, errors.New("deck, model and fields are required")
+	}
+	fields, ok := c.models[added.ModelName]
+	if !ok {
+		fields = make([]string, 0, len(added.Fields))
+		for name := range added.Fields {
+			fields = append(fields, name)
+		}
+		// Fields are sent as a map, the stand-in orders them by name with Front first
+		slices.SortFunc(fields, func(a, b string) int {
+			switch {
+			case a == "Front":
+				return -1
+			case b == "Front":
+				return 1
+			}
+			return strings.Compare(a, b)
+		})
+	}
+	values := make(ankiconnect.Fields, len(fields))
+	for _, name := range fields {
+		values[name] = added.Fields[name]
+	}
+
+	allowDuplicate := added.Options != nil && added.Options.AllowDuplicate
+	for _, n := range c.notes {
+		if !allowDuplicate && n.deck == added.DeckName && n.model == added.ModelName && n.values[n.fields[0]] == added.Fields[fields[0]] {
+			return 0, errors.New("cannot create note because it is a duplicate")
+		}
+	}
+
+	c.lastID++
+	c.notes[c.lastID] = &note{
+		id:     c.lastID,
+		deck:   added.DeckName,
+		model:  added.ModelName,
+		fields: fields,
+		values: values,
+		tags:   added.Tags,
+	}
+	return c.lastID, nil
+}
+
+// searchTerm matches a quoted or bare term of an Anki search, such as "deck:Francais\_mots" or tag:food
+var searchTerm = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|(\S+)`)
+
+// find returns the notes matching every term of the query. Only the tag, deck and note terms are supported
+func (c *Collection) find(query string) ([]int64, error) {
+	type filter struct {
+		name    string
+		pattern *regexp.Regexp
+	}
+	filters := make([]filter, 0)
+	for _, match := range searchTerm.FindAllStringSubmatch(query, -1) {
+		term := match[1] + match[2]
+		name, value, ok := strings.Cut(term, ":")
+		if !ok || !slices.Contains([]string{"tag", "deck", "note"}, name) {
+			return nil, fmt.Errorf("unsupported search term %q", term)
+		}
+		filters = append(filters, filter{name: name, pattern: searchPattern(value)})
+	}
+
+	ids := make([]int64, 0)
+	for id, n := range c.notes {
+		matches := true
+		for _, f := range filters {
+			switch f.name {
+			case "tag":
+				matches = matches && slices.ContainsFunc(n.tags, f.pattern.MatchString)
+			case "deck":
+				matches = matches && f.pattern.MatchString(n.deck)
+			case "note":
+				matches = matches && f.pattern.MatchString(n.model)
+			}
+		}
+		if matches {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// searchPattern turns a search value into a case-insensitive pattern: * and _ are wildcards unless escaped
+func searchPattern(value string) *regexp.Regexp {
+	var pattern strings.Builder
+	pattern.WriteString("(?i)^")
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; {
+		case c == '\\' && i+1 < len(value):
+			i++
+			pattern.WriteString(regexp.QuoteMeta(value[i : i+1]))
+		case c == '*':
+			pattern.WriteString(".*")
+		case c == '_':
+			pattern.WriteString(".")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(value[i : i+1]))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String())
+}
@@ -1,18 +1,15 @@
 package client
 
 import (
-	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
 	"github.com/marycka9/go-reverso-api/languages"
 	"github.com/marycka9/go-reverso-api/voices"
-	"io"
-	"log"
 	"net/http"
-	"os"
+	"slices"
 	"strings"
 )
 
@@ -26,11 +23,20 @@ func NewClient() *Client {
 	}
 }
 
+// SourceName marks the client as a dictionary source, see repositories.Source
+func (c *Client) SourceName() string {
+	return "Reverso"
+}
+
 func (c *Client) Close() {
 	c.Close()
 }
 
 func (c *Client) Translate(text string, srcLang, dstLang *languages.Language) (*entities.TranslateResponse, error) {
+	if err := languages.CheckPair(languages.ServiceTranslate, srcLang, dstLang); err != nil {
+		return nil, err
+	}
+
 	translateReq := entities.NewTranslateRequest(text, srcLang, dstLang)
 	requestBody, err := translateReq.MarshalJson()
 	if err != nil {
@@ -62,13 +68,25 @@ func (c *Client) Translate(text string, srcLang, dstLang *languages.Language) (*
 }
 
 func (c *Client) Synonyms(text string, language *languages.Language) (*entities.SynonymsResponse, error) {
-	synonymRequest := entities.NewSynonymRequest(text, language)
+	return c.SynonymsWithOptions(text, language, entities.DefaultSynonymOptions())
+}
+
+// SynonymsWithOptions searches the synonyms of the text with the given query parameters
+func (c *Client) SynonymsWithOptions(text string, language *languages.Language, opts entities.SynonymOptions) (*entities.SynonymsResponse, error) {
+	if err := languages.CheckLanguage(languages.ServiceSynonyms, language); err != nil {
+		return nil, err
+	}
+
+	synonymRequest := entities.NewSynonymRequest(text, language, opts)
 
 	req, err := http.NewRequest(
 		http.MethodGet,
-		synonymRequest.GetUrl(language.Code, text),
+		synonymRequest.GetUrl(string(language.Code), text),
 		nil,
 	)
+	if err != nil {
+		return nil, err
+	}
 
 	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
 	req.Header.Add("User-Agent", "")
@@ -88,12 +106,63 @@ func (c *Client) Synonyms(text string, language *languages.Language) (*entities.
 	return synonym, nil
 }
 
+// EachSynonymsPage pages through the synonyms of the text starting at opts.Page and calls fn for every page
+// until fn returns false or a page brings no new synonyms
+func (c *Client) EachSynonymsPage(text string, language *languages.Language, opts entities.SynonymOptions, fn func(page *entities.SynonymsResponse) bool) error {
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	// A page shorter than the limit is the last one, so the limit is always sent
+	if opts.Limit < 1 {
+		opts.Limit = entities.DefaultSynonymOptions().Limit
+	}
+
+	seen := make(map[int64]bool)
+	for {
+		page, err := c.SynonymsWithOptions(text, language, opts)
+		if err != nil {
+			return err
+		}
+
+		ids := page.ClusterIDs()
+		fresh := 0
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				fresh++
+			}
+		}
+		if fresh == 0 {
+			return nil
+		}
+
+		if !fn(page) || len(ids) < opts.Limit {
+			return nil
+		}
+		opts.Page++
+	}
+}
+
+// Antonyms returns the antonyms of the text the filter allows, the zero filter keeps the rude and colloquial ones
+func (c *Client) Antonyms(text string, language *languages.Language, filter entities.SynonymFilter) ([]entities.Antonym, error) {
+	synonym, err := c.Synonyms(text, language)
+	if err != nil {
+		return nil, err
+	}
+
+	return synonym.AllAntonyms(filter), nil
+}
+
 func (c *Client) AutoComplete(text string, language *languages.Language) (*entities.AutoCompleteResponse, error) {
+	if err := languages.CheckLanguage(languages.ServiceSynonyms, language); err != nil {
+		return nil, err
+	}
+
 	autoCompleteRequest := entities.NewAutoCompleteRequest()
 
 	req, err := http.NewRequest(
 		http.MethodGet,
-		autoCompleteRequest.GetUrl(language.Code, text),
+		autoCompleteRequest.GetUrl(string(language.Code), text),
 		nil,
 	)
 
@@ -119,6 +188,10 @@ func (c *Client) AutoComplete(text string, language *languages.Language) (*entit
 }
 
 func (c *Client) Context(text string, srcLang, dstLang *languages.Language, page int) (*entities.ContextResponse, error) {
+	if err := languages.CheckPair(languages.ServiceContext, srcLang, dstLang); err != nil {
+		return nil, err
+	}
+
 	queryReq := entities.NewContextRequest(text, srcLang, dstLang, page)
 
 	req, err := http.NewRequest(
@@ -149,7 +222,16 @@ func (c *Client) Context(text string, srcLang, dstLang *languages.Language, page
 }
 
 func (c *Client) Suggest(text string, srcLang, dstLang *languages.Language) (*entities.SuggestResponse, error) {
-	suggestReq := entities.NewSuggestRequest(text, srcLang, dstLang)
+	return c.SuggestWithOptions(text, srcLang, dstLang, entities.SuggestOptions{})
+}
+
+// SuggestWithOptions fetches search suggestions for the text with the given max results and mode
+func (c *Client) SuggestWithOptions(text string, srcLang, dstLang *languages.Language, opts entities.SuggestOptions) (*entities.SuggestResponse, error) {
+	if err := languages.CheckPair(languages.ServiceContext, srcLang, dstLang); err != nil {
+		return nil, err
+	}
+
+	suggestReq := entities.NewSuggestRequest(text, srcLang, dstLang, opts)
 
 	req, err := http.NewRequest(
 		http.MethodGet,
@@ -179,62 +261,34 @@ func (c *Client) Suggest(text string, srcLang, dstLang *languages.Language) (*en
 }
 
 func (c *Client) Speak(fileName, filePath, text string, mp3BitRate, voiceSpeed int) error {
-	speakRequest, err := entities.NewSpeakRequest(fileName, filePath, text, voices.VoiceEnglishFemale, mp3BitRate, voiceSpeed)
-	if err != nil {
-		return err
-	}
-
-	if _, err := os.Stat(speakRequest.FilePath); errors.Is(err, os.ErrNotExist) {
-		err := os.Mkdir(speakRequest.FilePath, os.ModePerm)
-		if err != nil {
-			log.Println(err)
-		}
-	}
+	_, err := c.speak(fileName, filePath, text, voices.VoiceEnglishFemale, mp3BitRate, voiceSpeed)
+	return err
+}
 
-	fileOut, err := os.OpenFile(speakRequest.GetPath(), os.O_CREATE|os.O_WRONLY, 0644)
+// speak saves the voice reading the text and returns the path of the audio file
+func (c *Client) speak(fileName, filePath, text, voice string, mp3BitRate, voiceSpeed int) (string, error) {
+	speakRequest, err := entities.NewSpeakRequest(fileName, filePath, text, voice, mp3BitRate, voiceSpeed)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	req, err := http.NewRequest(
 		http.MethodGet,
-		speakRequest.GetUrl(voices.VoiceEnglishFemale),
+		speakRequest.GetUrl(speakRequest.Voice),
 		nil,
 	)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	req.Header.Add("Content-Type", "application/json; charset=UTF-8")
 	req.Header.Add("User-Agent", entities.UserAgentContextApp)
 
-	resp, err := c.Client.Do(req)
-	if err != nil {
-		return err
+	if err := common.SaveMedia(c.Client, req, speakRequest.GetPath()); err != nil {
+		return "", err
 	}
 
-	var buffer *bytes.Buffer
-	var body []byte
-
-	buffer = bytes.NewBuffer(body)
-
-	if resp.ContentLength == -1 {
-		_, err = buffer.ReadFrom(resp.Body)
-	} else {
-		body = make([]byte, resp.ContentLength)
-		_, err = io.Copy(buffer, resp.Body)
-	}
-
-	body = buffer.Bytes()
-	_ = resp.Body.Close()
-
-	if _, err = fileOut.Write(body); err != nil {
-		return err
-	}
-
-	_ = fileOut.Close()
-
-	return nil
+	return speakRequest.GetPath(), nil
 }
 
 func (c *Client) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *languages.Language) ([]string, error) {
@@ -246,7 +300,7 @@ func (c *Client) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *
 	// TODO :: Transfer it to args as it can be used to determine the level of understanding of the language
 	i := 0
 	for _, result := range res.ContextResults.Results {
-		if strings.Contains(result.PartOfSpeech, partOfSpeech) {
+		if result.HasPartOfSpeech(partOfSpeech) {
 			if i >= 1 {
 				break
 			}
@@ -257,21 +311,78 @@ func (c *Client) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *
 	return translations, nil
 }
 
-func (c *Client) FetchTranscription(term string, srcLang, dstLang entities.Language) (string, error) {
-	return "", nil
+// FetchExamples returns the first page of Reverso Context example sentences for the term
+func (c *Client) FetchExamples(term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, error) {
+	res, err := c.Context(term, srcLang, dstLang, 1)
+	if err != nil {
+		return nil, err
+	}
+	examples := make([]entities.ExamplePair, 0, len(res.List))
+	for _, example := range res.List {
+		examples = append(examples, entities.ExamplePair{Source: example.SText, Target: example.TText})
+	}
+	return examples, nil
 }
+
+// FetchAdditionalData completes the gender of a noun from the Context dictionary entries of its translations:
+// looking a translation up back into the language of the word gives the word tagged "nm", "nf"...
 func (c *Client) FetchAdditionalData(word *entities.Word) error {
+	if word.Morphology.PartOfSpeech != entities.PosNoun || word.Morphology.Gender != entities.GenderNone {
+		return nil
+	}
+	dstLang, err := languages.Get(word.Language)
+	if err != nil {
+		return err
+	}
+
+	codes := make([]languages.Code, 0, len(word.Translations))
+	for code := range word.Translations {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	term := common.NormalizeTerm(word.Term, word.Language)
+	for _, code := range codes {
+		srcLang, err := languages.Get(code)
+		if err != nil || languages.CheckPair(languages.ServiceContext, srcLang, dstLang) != nil {
+			continue
+		}
+		for _, translation := range word.Translations[code] {
+			res, err := c.Context(translation, srcLang, dstLang, 1)
+			if err != nil {
+				return err
+			}
+			for _, entry := range res.DictionaryEntryList {
+				if morphology := entry.Morphology(); morphology.Gender != entities.GenderNone && common.NormalizeTerm(entry.Term, word.Language) == term {
+					word.SetMorphology(morphology)
+					return nil
+				}
+			}
+		}
+	}
 	return nil
 }
 
-func (c *Client) FetchConjugation(term string, lang entities.Language) (*entities.FrenchVerbConjugation, error) {
-	// Проверка языка (допустим, только французский поддерживается)
-	if lang != "french" {
-		return nil, fmt.Errorf("язык %s не поддерживается для спряжения", lang)
+// FetchPronunciation saves the Reverso voice reading the term as dir/<term>.mp3, see common.MediaFileName
+func (c *Client) FetchPronunciation(term string, lang *languages.Language, dir string) (string, error) {
+	if err := languages.CheckLanguage(languages.ServiceSpeak, lang); err != nil {
+		return "", err
+	}
+	voice, ok := voices.ForLanguage(lang.Code)
+	if !ok {
+		return "", fmt.Errorf("no voice for %s", lang.Name)
+	}
+	return c.speak(common.MediaFileName(term), dir, term, voice, 128, 100)
+}
+
+func (c *Client) FetchConjugation(term string, lang *languages.Language) (*entities.FrenchVerbConjugation, error) {
+	// Проверка языка по матрице возможностей сервиса
+	if err := languages.CheckLanguage(languages.ServiceConjugator, lang); err != nil {
+		return nil, err
 	}
 
 	// Формируем URL, заменяя "aller" на нужный глагол.
-	url := fmt.Sprintf("https://conjugator.reverso.net/conjugation-french-verb-%s.html", strings.ToLower(term))
+	url := fmt.Sprintf("https://conjugator.reverso.net/conjugation-%s-verb-%s.html", lang.Name, strings.ToLower(term))
 
 	// Создаём новый HTTP-запрос.
 	req, err := http.NewRequest(http.MethodGet, url, nil)
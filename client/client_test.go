@@ -0,0 +1,188 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// fixtureTransport answers the synonyms searches with the pages of testdata/synonyms_<word>_page<n>.json and
+// records the query of every request
+type fixtureTransport struct {
+	word    string
+	queries []url.Values
+}
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	t.queries = append(t.queries, query)
+
+	page := query.Get("page")
+	if page == "" {
+		page = "1"
+	}
+	body, err := os.ReadFile(filepath.Join("testdata", fmt.Sprintf("synonyms_%s_page%s.json", t.word, page)))
+	if os.IsNotExist(err) {
+		body, err = []byte(`{"results": []}`), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+func TestSynonymsWithOptionsParams(t *testing.T) {
+	transport := &fixtureTransport{word: "chien"}
+	c := &Client{Client: &http.Client{Transport: transport}}
+
+	opts := entities.SynonymOptions{Limit: 20, Page: 2, Rude: false, Colloquial: true, Merge: true}
+	res, err := c.SynonymsWithOptions("chien", languages.MustGet(languages.French), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := url.Values{
+		"rude":       {"false"},
+		"colloquial": {"true"},
+		"abc":        {"false"},
+		"merge":      {"true"},
+		"limit":      {"20"},
+		"page":       {"2"},
+	}
+	if got := transport.queries[0]; got.Encode() != want.Encode() {
+		t.Errorf("query = %s, want %s", got.Encode(), want.Encode())
+	}
+	if got := res.SynonymsByPos(entities.SynonymFilter{})["v"]; len(got) != 1 || got[0].Word != "chienner" {
+		t.Errorf("verb synonyms of page 2 = %v, want chienner", got)
+	}
+}
+
+func TestSynonymsPartOfSpeechParam(t *testing.T) {
+	tests := []struct {
+		name string
+		mask int64
+		pos  string
+	}{
+		{name: "every part of speech"},
+		{name: "mask of a response", mask: 2, pos: "2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &fixtureTransport{word: "chien"}
+			c := &Client{Client: &http.Client{Transport: transport}}
+
+			opts := entities.DefaultSynonymOptions()
+			opts.PartOfSpeech = test.mask
+			if _, err := c.SynonymsWithOptions("chien", languages.MustGet(languages.French), opts); err != nil {
+				t.Fatal(err)
+			}
+			if got := transport.queries[0].Get("pos"); got != test.pos {
+				t.Errorf("pos = %q, want %q", got, test.pos)
+			}
+		})
+	}
+}
+
+func TestSynonymsFirstPageParams(t *testing.T) {
+	transport := &fixtureTransport{word: "chien"}
+	c := &Client{Client: &http.Client{Transport: transport}}
+
+	if _, err := c.SynonymsWithOptions("chien", languages.MustGet(languages.French), entities.SynonymOptions{Page: 1}); err != nil {
+		t.Fatal(err)
+	}
+	query := transport.queries[0]
+	for _, name := range []string{"page", "limit"} {
+		if query.Has(name) {
+			t.Errorf("%s = %q, want it left to Reverso", name, query.Get(name))
+		}
+	}
+}
+
+func TestEachSynonymsPage(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		pages []string
+		words []string
+	}{
+		{name: "full first page", limit: 2, pages: []string{"", "2"}, words: []string{"toutou", "cabot", "chienner"}},
+		{name: "short first page", limit: 5, pages: []string{""}, words: []string{"toutou", "cabot"}},
+		// Without a limit the default one is sent, so the short first page still ends the search
+		{name: "no limit", limit: 0, pages: []string{""}, words: []string{"toutou", "cabot"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport := &fixtureTransport{word: "chien"}
+			c := &Client{Client: &http.Client{Transport: transport}}
+
+			words := make([]string, 0)
+			opts := entities.SynonymOptions{Limit: test.limit}
+			err := c.EachSynonymsPage("chien", languages.MustGet(languages.French), opts, func(page *entities.SynonymsResponse) bool {
+				for _, sense := range page.Senses(entities.SynonymFilter{}) {
+					for _, synonym := range sense.Synonyms {
+						words = append(words, synonym.Word)
+					}
+				}
+				return true
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			pages := make([]string, 0, len(transport.queries))
+			for _, query := range transport.queries {
+				pages = append(pages, query.Get("page"))
+				if query.Get("limit") == "" {
+					t.Errorf("request without a limit: %s", query.Encode())
+				}
+			}
+			if !slices.Equal(pages, test.pages) {
+				t.Errorf("pages = %q, want %q", pages, test.pages)
+			}
+			if !slices.Equal(words, test.words) {
+				t.Errorf("words = %q, want %q", words, test.words)
+			}
+		})
+	}
+}
+
+func TestAntonymsFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter entities.SynonymFilter
+		words  []string
+	}{
+		{name: "every antonym", words: []string{"petit", "riquiqui"}},
+		{name: "without colloquial ones", filter: entities.SynonymFilter{ExcludeColloquial: true}, words: []string{"petit"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Client{Client: &http.Client{Transport: &fixtureTransport{word: "grand"}}}
+			antonyms, err := c.Antonyms("grand", languages.MustGet(languages.French), test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			words := make([]string, 0, len(antonyms))
+			for _, antonym := range antonyms {
+				words = append(words, antonym.Word)
+			}
+			if !slices.Equal(words, test.words) {
+				t.Errorf("antonyms = %q, want %q", words, test.words)
+			}
+		})
+	}
+}
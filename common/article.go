@@ -0,0 +1,112 @@
+package common
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// definiteArticles holds the definite articles of each language by gender
+var definiteArticles = map[languages.Code]map[string]string{
+	languages.French:     {Masculine: "le", Feminine: "la", MasculineFeminine: "le/la"},
+	languages.Spanish:    {Masculine: "el", Feminine: "la", MasculineFeminine: "el/la"},
+	languages.Italian:    {Masculine: "il", Feminine: "la", MasculineFeminine: "il/la"},
+	languages.Portuguese: {Masculine: "o", Feminine: "a", MasculineFeminine: "o/a"},
+	languages.German:     {Masculine: "der", Feminine: "die", Neuter: "das", MasculineFeminine: "der/die"},
+	languages.Dutch:      {Masculine: "de", Feminine: "de", Neuter: "het", MasculineFeminine: "de"},
+}
+
+// vowels are the letters an article is elided before, once accents are removed. The y of "yaourt" or "yoga" is not
+// one: "le yaourt", "lo yogurt"
+const vowels = "aeiouœæ"
+
+// hAspire are the common French words starting with an h aspiré, without accents: their article is not elided,
+// "le héros", "la hache". Words missing from the list are elided like the ones starting with an h muet
+var hAspire = map[string]bool{
+	"hache": true, "hachis": true, "haie": true, "haillon": true, "haine": true, "hall": true, "halte": true,
+	"hamac": true, "hamburger": true, "hameau": true, "hamster": true, "hanche": true, "handball": true,
+	"handicap": true, "hangar": true, "hanneton": true, "harem": true, "hareng": true, "haricot": true,
+	"harnais": true, "harpe": true, "harpon": true, "hasard": true, "hate": true, "hausse": true, "haut": true,
+	"hauteur": true, "havre": true, "hennissement": true, "hernie": true, "heron": true, "heros": true,
+	"herisson": true, "hetre": true, "hibou": true, "hierarchie": true, "hockey": true, "homard": true,
+	"honte": true, "hoquet": true, "horde": true, "hors-d'œuvre": true, "hotte": true, "houblon": true,
+	"houle": true, "housse": true, "houx": true, "hublot": true, "huee": true, "huit": true, "hurlement": true,
+	"hutte": true,
+}
+
+// DefiniteArticle returns the definite article of a noun, or an empty string when the language or the gender has none.
+// French and Italian elide it before a vowel, French also before an h unless the word is one of hAspire
+func DefiniteArticle(term, gender string, lang languages.Code) string {
+	article := definiteArticles[lang][gender]
+	if article == "" {
+		return ""
+	}
+
+	// Without accents, "élève" elides like "eleve"
+	folded := NormalizeTerm(term, "")
+	first, _ := utf8.DecodeRuneInString(folded)
+	vowel := strings.ContainsRune(vowels, first)
+	switch lang {
+	case languages.French:
+		firstWord, _, _ := strings.Cut(folded, " ")
+		if vowel || strings.HasPrefix(folded, "h") && !hAspire[firstWord] {
+			return "l'"
+		}
+	case languages.Italian:
+		if vowel {
+			return "l'"
+		}
+		impureS := len(folded) > 1 && folded[0] == 's' && !strings.ContainsRune(vowels, rune(folded[1]))
+		if gender == Masculine && (impureS || strings.HasPrefix(folded, "z") || strings.HasPrefix(folded, "gn") ||
+			strings.HasPrefix(folded, "ps") || strings.HasPrefix(folded, "x") || strings.HasPrefix(folded, "y")) {
+			return "lo"
+		}
+	}
+	return article
+}
+
+// frenchPluralExceptions are the nouns whose plural the rules of FrenchPlural get wrong. Nouns told apart by their
+// accents only, "émail" and "email", are written with them
+var frenchPluralExceptions = map[string]string{
+	"bal": "bals", "carnaval": "carnavals", "chacal": "chacals", "festival": "festivals", "recital": "récitals",
+	"regal": "régals", "bail": "baux", "corail": "coraux", "émail": "émaux", "email": "emails", "soupirail": "soupiraux",
+	"travail": "travaux", "vantail": "vantaux", "vitrail": "vitraux", "bijou": "bijoux", "caillou": "cailloux",
+	"chou": "choux", "genou": "genoux", "hibou": "hiboux", "joujou": "joujoux", "pou": "poux", "bleu": "bleus",
+	"pneu": "pneus", "landau": "landaus", "sarrau": "sarraus", "oeil": "yeux", "œil": "yeux", "ciel": "cieux", "aieul": "aïeux",
+}
+
+// FrenchPlural returns the plural of a French noun by the rules of the grammar and their exceptions, or an empty
+// string for compound nouns, whose plural depends on their parts
+func FrenchPlural(term string) string {
+	term = strings.TrimSpace(term)
+	if term == "" || strings.ContainsAny(term, " -'") {
+		return ""
+	}
+	if plural, ok := frenchPluralExceptions[strings.ToLower(term)]; ok {
+		return plural
+	}
+	if plural, ok := frenchPluralExceptions[NormalizeTerm(term, "")]; ok {
+		return plural
+	}
+	switch {
+	case strings.HasSuffix(term, "s"), strings.HasSuffix(term, "x"), strings.HasSuffix(term, "z"):
+		return term
+	case strings.HasSuffix(term, "al"):
+		return strings.TrimSuffix(term, "al") + "aux"
+	case strings.HasSuffix(term, "au"), strings.HasSuffix(term, "eu"):
+		return term + "x"
+	}
+	return term + "s"
+}
+
+// WithArticle prefixes the term with its article, without a space after an elided one
+func WithArticle(article, term string) string {
+	switch {
+	case article == "":
+		return term
+	case strings.HasSuffix(article, "'"):
+		return article + term
+	}
+	return article + " " + term
+}
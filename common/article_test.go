@@ -0,0 +1,69 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+func TestDefiniteArticle(t *testing.T) {
+	tests := []struct {
+		term   string
+		gender string
+		lang   languages.Code
+		want   string
+	}{
+		{term: "chien", gender: Masculine, lang: languages.French, want: "le"},
+		{term: "table", gender: Feminine, lang: languages.French, want: "la"},
+		{term: "élève", gender: MasculineFeminine, lang: languages.French, want: "l'"},
+		{term: "arbre", gender: Masculine, lang: languages.French, want: "l'"},
+		{term: "œil", gender: Masculine, lang: languages.French, want: "l'"},
+		{term: "œuvre", gender: Feminine, lang: languages.French, want: "l'"},
+		{term: "yaourt", gender: Masculine, lang: languages.French, want: "le"},
+		{term: "yoga", gender: Masculine, lang: languages.French, want: "le"},
+		{term: "homme", gender: Masculine, lang: languages.French, want: "l'"},
+		{term: "héros", gender: Masculine, lang: languages.French, want: "le"},
+		{term: "hache", gender: Feminine, lang: languages.French, want: "la"},
+		{term: "amico", gender: Masculine, lang: languages.Italian, want: "l'"},
+		{term: "yogurt", gender: Masculine, lang: languages.Italian, want: "lo"},
+		{term: "studente", gender: Masculine, lang: languages.Italian, want: "lo"},
+		{term: "zio", gender: Masculine, lang: languages.Italian, want: "lo"},
+		{term: "Haus", gender: Neuter, lang: languages.German, want: "das"},
+		{term: "dog", gender: Masculine, lang: languages.English, want: ""},
+	}
+	for _, test := range tests {
+		t.Run(string(test.lang)+" "+test.term, func(t *testing.T) {
+			if got := DefiniteArticle(test.term, test.gender, test.lang); got != test.want {
+				t.Errorf("article = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFrenchPlural(t *testing.T) {
+	tests := []struct {
+		term string
+		want string
+	}{
+		{term: "chien", want: "chiens"},
+		{term: "cheval", want: "chevaux"},
+		{term: "festival", want: "festivals"},
+		{term: "gâteau", want: "gâteaux"},
+		{term: "pneu", want: "pneus"},
+		{term: "souris", want: "souris"},
+		{term: "travail", want: "travaux"},
+		{term: "détail", want: "détails"},
+		{term: "émail", want: "émaux"},
+		{term: "email", want: "emails"},
+		{term: "œil", want: "yeux"},
+		{term: "genou", want: "genoux"},
+		{term: "pomme de terre", want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.term, func(t *testing.T) {
+			if got := FrenchPlural(test.term); got != test.want {
+				t.Errorf("plural = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"strings"
+)
+
+// Grammatical genders returned by ParseGender
+const (
+	Masculine = "m"
+	Feminine  = "f"
+	Neuter    = "n"
+	// MasculineFeminine is the gender of nouns taking either article, such as "élève"
+	MasculineFeminine = "mf"
+)
+
+// genderLabels maps the words and abbreviations dictionaries use for genders
+var genderLabels = map[string]string{
+	"m":         Masculine,
+	"nm":        Masculine,
+	"masc":      Masculine,
+	"masculin":  Masculine,
+	"masculine": Masculine,
+	"f":         Feminine,
+	"nf":        Feminine,
+	"fem":       Feminine,
+	"fém":       Feminine,
+	"féminin":   Feminine,
+	"feminine":  Feminine,
+	"nt":        Neuter,
+	"neut":      Neuter,
+	"neutre":    Neuter,
+	"neuter":    Neuter,
+	"mf":        MasculineFeminine,
+	"nmf":       MasculineFeminine,
+}
+
+// invariableLabels are the words dictionaries use for nouns and adjectives without a plural form
+var invariableLabels = map[string]bool{
+	"inv":        true,
+	"invar":      true,
+	"invariable": true,
+}
+
+// labelWords splits a label such as "nom masculin invariable", "n.m." or "[ masculine ]" into lowercase words
+func labelWords(label string) []string {
+	return strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return strings.ContainsRune(" ,/;|.()[]", r)
+	})
+}
+
+// ParseGender returns the gender named by a dictionary label, or an empty string when it names none
+func ParseGender(label string) string {
+	var masculine, feminine, neuter bool
+	for _, word := range labelWords(label) {
+		switch genderLabels[word] {
+		case Masculine:
+			masculine = true
+		case Feminine:
+			feminine = true
+		case Neuter:
+			neuter = true
+		case MasculineFeminine:
+			masculine, feminine = true, true
+		}
+	}
+
+	switch {
+	case masculine && feminine:
+		return MasculineFeminine
+	case masculine:
+		return Masculine
+	case feminine:
+		return Feminine
+	case neuter:
+		return Neuter
+	}
+	return ""
+}
+
+// IsInvariable reports whether a dictionary label marks the word as invariable
+func IsInvariable(label string) bool {
+	for _, word := range labelWords(label) {
+		if invariableLabels[word] {
+			return true
+		}
+	}
+	return false
+}
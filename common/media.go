@@ -0,0 +1,56 @@
+package common
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// MediaFileName turns a term into a file name: path separators, characters Windows refuses and control characters
+// become '_', so a term such as "c/o" or "../x" stays in the directory it is saved to
+func MediaFileName(term string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(term))
+	name = strings.TrimLeft(name, ".")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// SaveMedia performs the request and writes the response body to path, creating its directory if needed
+func SaveMedia(client *http.Client, req *http.Request, path string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: unexpected status %s", req.URL, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	fileOut, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(fileOut, resp.Body); err != nil {
+		_ = fileOut.Close()
+		return err
+	}
+
+	return fileOut.Close()
+}
@@ -0,0 +1,183 @@
+package common
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Verb and noun sub-types returned by ParseSubTypes
+const (
+	Transitive   = "transitive"
+	Intransitive = "intransitive"
+	Pronominal   = "pronominal"
+	Phrasal      = "phrasal"
+	Auxiliary    = "auxiliary"
+	Impersonal   = "impersonal"
+	Countable    = "countable"
+	Uncountable  = "uncountable"
+)
+
+// Grammatical numbers returned by ParseNumber
+const (
+	Singular   = "sg"
+	Plural     = "pl"
+	Invariable = "inv"
+)
+
+// Register labels returned by ParseRegisters
+const (
+	Formal       = "formal"
+	Informal     = "informal"
+	Slang        = "slang"
+	Vulgar       = "vulgar"
+	Literary     = "literary"
+	OldFashioned = "old-fashioned"
+	Humorous     = "humorous"
+	Specialized  = "specialized"
+)
+
+// subTypeLabels maps the words and abbreviations of Larousse, Cambridge and Reverso for sub-types
+var subTypeLabels = map[string][]string{
+	"vt":            {Transitive},
+	"transitif":     {Transitive},
+	"transitive":    {Transitive},
+	"vi":            {Intransitive},
+	"intransitif":   {Intransitive},
+	"intransitive":  {Intransitive},
+	"vti":           {Transitive, Intransitive},
+	"vpr":           {Pronominal},
+	"pronominal":    {Pronominal},
+	"reflexive":     {Pronominal},
+	"phrasal":       {Phrasal},
+	"auxiliaire":    {Auxiliary},
+	"auxiliary":     {Auxiliary},
+	"aux":           {Auxiliary},
+	"impersonnel":   {Impersonal},
+	"impersonal":    {Impersonal},
+	"countable":     {Countable},
+	"uncountable":   {Uncountable},
+	"indénombrable": {Uncountable},
+}
+
+// grammarCodes are the one-letter sub-type codes. Any word of one letter would match them, so they are only read
+// where they are abbreviations: followed by a dot as in "v. t.", or between brackets as Cambridge writes them, [ T ]
+// or [ I or T ]
+var grammarCodes = map[string][]string{
+	"t": {Transitive},
+	"i": {Intransitive},
+	"c": {Countable},
+	"u": {Uncountable},
+}
+
+var brackets = regexp.MustCompile(`\[([^\]]*)\]`)
+
+// grammarCodeWords returns the one-letter words of the label written as abbreviations, see grammarCodes
+func grammarCodeWords(label string) []string {
+	res := make([]string, 0)
+	letters := []rune(label)
+	for i, r := range letters {
+		if unicode.IsLetter(r) && (i == 0 || !unicode.IsLetter(letters[i-1])) && i+1 < len(letters) && letters[i+1] == '.' {
+			res = append(res, strings.ToLower(string(r)))
+		}
+	}
+	for _, match := range brackets.FindAllStringSubmatch(label, -1) {
+		for _, word := range labelWords(match[1]) {
+			if len([]rune(word)) == 1 {
+				res = append(res, word)
+			}
+		}
+	}
+	return res
+}
+
+// numberLabels maps the words and abbreviations dictionaries use for numbers
+var numberLabels = map[string]string{
+	"sg":        Singular,
+	"sing":      Singular,
+	"singulier": Singular,
+	"singular":  Singular,
+	"pl":        Plural,
+	"npl":       Plural,
+	"pluriel":   Plural,
+	"plural":    Plural,
+}
+
+// registerLabels maps the usage labels of the dictionaries to registers
+var registerLabels = map[string]string{
+	"soutenu":       Formal,
+	"formal":        Formal,
+	"fml":           Formal,
+	"familier":      Informal,
+	"fam":           Informal,
+	"informal":      Informal,
+	"colloquial":    Informal,
+	"argot":         Slang,
+	"slang":         Slang,
+	"vulgaire":      Vulgar,
+	"vulg":          Vulgar,
+	"vulgar":        Vulgar,
+	"offensive":     Vulgar,
+	"littéraire":    Literary,
+	"littér":        Literary,
+	"literary":      Literary,
+	"vieilli":       OldFashioned,
+	"vieux":         OldFashioned,
+	"old-fashioned": OldFashioned,
+	"dated":         OldFashioned,
+	"humoristique":  Humorous,
+	"humorous":      Humorous,
+	"specialized":   Specialized,
+	"technique":     Specialized,
+}
+
+// appendLabels appends the values of the label words found in labels, without duplicates
+func appendLabels(res []string, label string, labels func(word string) []string) []string {
+	for _, word := range labelWords(label) {
+		for _, value := range labels(word) {
+			if !slices.Contains(res, value) {
+				res = append(res, value)
+			}
+		}
+	}
+	return res
+}
+
+// ParseSubTypes returns the sub-types named by a dictionary label, such as transitive for "verbe transitif"
+func ParseSubTypes(label string) []string {
+	res := appendLabels(make([]string, 0), label, func(word string) []string {
+		return subTypeLabels[word]
+	})
+	for _, code := range grammarCodeWords(label) {
+		for _, subType := range grammarCodes[code] {
+			if !slices.Contains(res, subType) {
+				res = append(res, subType)
+			}
+		}
+	}
+	return res
+}
+
+// ParseNumber returns the grammatical number named by a dictionary label, invariable winning over the others
+func ParseNumber(label string) string {
+	if IsInvariable(label) {
+		return Invariable
+	}
+	for _, word := range labelWords(label) {
+		if number, ok := numberLabels[word]; ok {
+			return number
+		}
+	}
+	return ""
+}
+
+// ParseRegisters returns the registers named by the usage labels of a dictionary
+func ParseRegisters(label string) []string {
+	return appendLabels(make([]string, 0), label, func(word string) []string {
+		if register, ok := registerLabels[word]; ok {
+			return []string{register}
+		}
+		return nil
+	})
+}
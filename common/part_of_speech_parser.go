@@ -1,13 +1,64 @@
 package common
 
 import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
 	"strings"
 	"sync"
+
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// Normalized parts of speech returned by the parser
+const (
+	Noun         = "n"
+	Verb         = "v"
+	Adjective    = "adj"
+	Adverb       = "adv"
+	Conjunction  = "conj"
+	Pronoun      = "pron"
+	Preposition  = "prep"
+	Interjection = "interj"
+	Article      = "art"
+)
+
+// partsOfSpeech are the normalized parts of speech vocabularies can map labels to
+var partsOfSpeech = []string{Noun, Verb, Adjective, Adverb, Conjunction, Pronoun, Preposition, Interjection, Article}
+
+// Vocabulary sources with labels of their own, see Vocabulary.Source
+const (
+	VocabularyReverso   = "reverso"
+	VocabularyCambridge = "cambridge"
+	VocabularyLarousse  = "larousse"
 )
 
+// ErrUnknownPartOfSpeech is returned when no vocabulary knows a label
+var ErrUnknownPartOfSpeech = errors.New("unknown part of speech")
+
+// Vocabulary maps the labels a language or a source uses to normalized parts of speech.
+// A vocabulary without language or source applies to every label
+type Vocabulary struct {
+	Language languages.Code    `json:"language,omitempty"`
+	Source   string            `json:"source,omitempty"`
+	Labels   map[string]string `json:"labels"`
+}
+
+type vocabularyKey struct {
+	language languages.Code
+	source   string
+}
+
 // PartOfSpeechParser handles parsing of part of speech names
 type PartOfSpeechParser struct {
-	mappings map[string]string
+	mu           sync.RWMutex
+	vocabularies map[vocabularyKey]map[string]string
+	order        []vocabularyKey // registration order, the one a label missing from the chain is looked for in
 }
 
 var (
@@ -15,41 +66,154 @@ var (
 	once     sync.Once
 )
 
-// NewPartOfSpeechParser creates a new instance of the parser
+//go:embed part_of_speech.json
+var vocabularyData []byte
+
+// NewPartOfSpeechParser creates a parser knowing only the given vocabularies
+func NewPartOfSpeechParser(vocabularies ...Vocabulary) (*PartOfSpeechParser, error) {
+	p := &PartOfSpeechParser{vocabularies: make(map[vocabularyKey]map[string]string)}
+	for _, vocabulary := range vocabularies {
+		if err := p.Register(vocabulary); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// GetPartOfSpeechParserInstance returns the shared parser, loaded with the embedded vocabularies
 func GetPartOfSpeechParserInstance() *PartOfSpeechParser {
 	once.Do(func() {
-		instance = &PartOfSpeechParser{
-			mappings: map[string]string{
-				"noun":         "n",
-				"n":            "n",
-				"verb":         "v",
-				"v":            "v",
-				"adjective":    "adj",
-				"adj":          "adj",
-				"adverb":       "adv",
-				"adv":          "adv",
-				"conjunction":  "conj",
-				"conj":         "conj",
-				"pronoun":      "pron",
-				"pron":         "pron",
-				"preposition":  "prep",
-				"prep":         "prep",
-				"préposition":  "prep",
-				"interjection": "interj",
-				"interj":       "interj",
-				"article":      "art",
-				"art":          "art",
-			},
+		instance, _ = NewPartOfSpeechParser()
+		if err := instance.LoadVocabularies(bytes.NewReader(vocabularyData)); err != nil {
+			panic(fmt.Sprintf("decode part_of_speech.json: %s", err))
 		}
 	})
 	return instance
 }
 
+// labelKey folds a label for lookups: lower case, no accents, no dots, so "N.M." and "nm" are the same label
+func labelKey(label string) string {
+	return strings.ReplaceAll(NormalizeTerm(label, ""), ".", "")
+}
+
+// knownPartOfSpeech reports whether the value is one of the normalized parts of speech
+func knownPartOfSpeech(partOfSpeech string) bool {
+	return slices.Contains(partsOfSpeech, partOfSpeech)
+}
+
+// Register adds the labels of the vocabulary, replacing the ones the parser already had for the language and source
+func (p *PartOfSpeechParser) Register(vocabulary Vocabulary) error {
+	labels := make(map[string]string, len(vocabulary.Labels))
+	for label, partOfSpeech := range vocabulary.Labels {
+		if !knownPartOfSpeech(partOfSpeech) {
+			return fmt.Errorf("label %q: %w: %q", label, ErrUnknownPartOfSpeech, partOfSpeech)
+		}
+		labels[labelKey(label)] = partOfSpeech
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := vocabularyKey{vocabulary.Language, vocabulary.Source}
+	if _, ok := p.vocabularies[key]; !ok {
+		p.order = append(p.order, key)
+		p.vocabularies[key] = make(map[string]string, len(labels))
+	}
+	for label, partOfSpeech := range labels {
+		p.vocabularies[key][label] = partOfSpeech
+	}
+	return nil
+}
+
+// LoadVocabularies registers the vocabularies of a JSON array in the format of part_of_speech.json
+func (p *PartOfSpeechParser) LoadVocabularies(r io.Reader) error {
+	var vocabularies []Vocabulary
+	if err := json.NewDecoder(r).Decode(&vocabularies); err != nil {
+		return err
+	}
+	for _, vocabulary := range vocabularies {
+		if err := p.Register(vocabulary); err != nil {
+			return fmt.Errorf("vocabulary %s/%s: %w", vocabulary.Language, vocabulary.Source, err)
+		}
+	}
+	return nil
+}
+
+// LoadVocabularyFile registers the vocabularies of a file, extending the parser without recompiling
+func (p *PartOfSpeechParser) LoadVocabularyFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return p.LoadVocabularies(file)
+}
+
+// lookup finds a folded label in the vocabularies of the language and the source, then the ones of the language,
+// of the source and the generic one. Without a language every vocabulary is searched, in registration order
+func (p *PartOfSpeechParser) lookup(key string, lang languages.Code, source string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	chain := []vocabularyKey{{lang, source}, {lang, ""}, {"", source}, {"", ""}}
+	if lang == "" {
+		chain = append(chain, p.order...)
+	}
+	for _, k := range chain {
+		if partOfSpeech, ok := p.vocabularies[k][key]; ok {
+			return partOfSpeech, true
+		}
+	}
+	return "", false
+}
+
 // Parse converts a full or shortened part of speech into a normalized format
-func (p *PartOfSpeechParser) Parse(partOfSpeech string) string {
-	partOfSpeech = strings.ToLower(strings.TrimSpace(partOfSpeech))
-	if normalized, exists := p.mappings[partOfSpeech]; exists {
-		return normalized
+func (p *PartOfSpeechParser) Parse(partOfSpeech string) (string, error) {
+	return p.ParseFor(partOfSpeech, "", "")
+}
+
+// ParseFor converts a part of speech written in the language or by the source into a normalized format,
+// returning the first one of compound labels
+func (p *PartOfSpeechParser) ParseFor(partOfSpeech string, lang languages.Code, source string) (string, error) {
+	if res := p.ParseAllFor(partOfSpeech, lang, source); len(res) > 0 {
+		return res[0], nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownPartOfSpeech, partOfSpeech)
+}
+
+// ParseAll splits a compound label such as "adj./adv." or "nom masculin" and returns every part of speech it names
+func (p *PartOfSpeechParser) ParseAll(partOfSpeech string) []string {
+	return p.ParseAllFor(partOfSpeech, "", "")
+}
+
+// ParseAllFor is ParseAll with the vocabularies of the language and the source. Whole labels such as
+// "phrasal verb" or "locution adverbiale" are looked up before their words
+func (p *PartOfSpeechParser) ParseAllFor(partOfSpeech string, lang languages.Code, source string) []string {
+	if normalized, ok := p.lookup(labelKey(partOfSpeech), lang, source); ok {
+		return []string{normalized}
+	}
+
+	words := strings.FieldsFunc(partOfSpeech, func(r rune) bool {
+		return strings.ContainsRune(" ,/;|()[]", r)
+	})
+
+	res := make([]string, 0, len(words))
+	for _, word := range words {
+		if normalized, ok := p.lookup(labelKey(word), lang, source); ok && !slices.Contains(res, normalized) {
+			res = append(res, normalized)
+		}
+	}
+	return res
+}
+
+// ParsePos decodes a Reverso Synonyms POS from its localized descriptions, such as "nom" or "verbe"
+func (p *PartOfSpeechParser) ParsePos(desc []string) []string {
+	res := make([]string, 0)
+	for _, d := range desc {
+		for _, normalized := range p.ParseAll(d) {
+			if !slices.Contains(res, normalized) {
+				res = append(res, normalized)
+			}
+		}
 	}
-	return "Unknown" // Default for unrecognized parts of speech
+	return res
 }
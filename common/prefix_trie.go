@@ -0,0 +1,97 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// PrefixTrie is an in-memory prefix index of weighted words. Keys are matched case-insensitively
+type PrefixTrie struct {
+	root *trieNode
+	size int
+}
+
+type trieNode struct {
+	children map[rune]*trieNode
+	word     string
+	weight   int64
+	terminal bool
+}
+
+// NewPrefixTrie creates an empty PrefixTrie
+func NewPrefixTrie() *PrefixTrie {
+	return &PrefixTrie{root: &trieNode{}}
+}
+
+// Insert adds the word, keeping the highest weight when it is already indexed
+func (t *PrefixTrie) Insert(word string, weight int64) {
+	node := t.root
+	for _, r := range strings.ToLower(word) {
+		if node.children == nil {
+			node.children = make(map[rune]*trieNode)
+		}
+		child, ok := node.children[r]
+		if !ok {
+			child = &trieNode{}
+			node.children[r] = child
+		}
+		node = child
+	}
+
+	if !node.terminal {
+		node.terminal = true
+		node.word = word
+		node.weight = weight
+		t.size++
+		return
+	}
+	if weight > node.weight {
+		node.weight = weight
+	}
+}
+
+// Complete returns at most n words starting with the prefix, heaviest first. A non-positive n returns them all
+func (t *PrefixTrie) Complete(prefix string, n int) []string {
+	node := t.root
+	for _, r := range strings.ToLower(prefix) {
+		child, ok := node.children[r]
+		if !ok {
+			return nil
+		}
+		node = child
+	}
+
+	found := make([]*trieNode, 0)
+	var walk func(node *trieNode)
+	walk = func(node *trieNode) {
+		if node.terminal {
+			found = append(found, node)
+		}
+		for _, child := range node.children {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].weight != found[j].weight {
+			return found[i].weight > found[j].weight
+		}
+		// Ties are ordered alphabetically, ignoring case like the keys
+		return strings.ToLower(found[i].word) < strings.ToLower(found[j].word)
+	})
+	if n > 0 && len(found) > n {
+		found = found[:n]
+	}
+
+	res := make([]string, 0, len(found))
+	for _, node := range found {
+		res = append(res, node.word)
+	}
+	return res
+}
+
+// Len returns the number of indexed words
+func (t *PrefixTrie) Len() int {
+	return t.size
+}
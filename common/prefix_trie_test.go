@@ -0,0 +1,38 @@
+package common
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPrefixTrie(t *testing.T) {
+	trie := NewPrefixTrie()
+	trie.Insert("chien", 10)
+	trie.Insert("chat", 30)
+	trie.Insert("Chaton", 20)
+	trie.Insert("chameau", 20)
+	trie.Insert("chien", 50) // the highest weight is kept
+	trie.Insert("chien", 5)
+	trie.Insert("vache", 40)
+
+	tests := []struct {
+		prefix string
+		n      int
+		want   []string
+	}{
+		{prefix: "ch", want: []string{"chien", "chat", "chameau", "Chaton"}},
+		{prefix: "CH", n: 2, want: []string{"chien", "chat"}},
+		{prefix: "chat", want: []string{"chat", "Chaton"}},
+		{prefix: "chaton", want: []string{"Chaton"}},
+		{prefix: "chats", want: nil},
+		{prefix: "", n: 1, want: []string{"chien"}},
+	}
+	for _, test := range tests {
+		if got := trie.Complete(test.prefix, test.n); !slices.Equal(got, test.want) {
+			t.Errorf("Complete(%q, %d) = %q, want %q", test.prefix, test.n, got, test.want)
+		}
+	}
+	if trie.Len() != 5 {
+		t.Errorf("Len() = %d, want 5", trie.Len())
+	}
+}
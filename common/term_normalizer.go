@@ -0,0 +1,40 @@
+package common
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/marycka9/go-reverso-api/languages"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// leadingArticles lists, per language, the articles and particles dropped from the start of a term
+var leadingArticles = map[languages.Code][]string{
+	languages.English:    {"the ", "a ", "an ", "to "},
+	languages.French:     {"le ", "la ", "les ", "l'", "un ", "une ", "des ", "se ", "s'"},
+	languages.Spanish:    {"el ", "la ", "los ", "las ", "un ", "una ", "unos ", "unas "},
+	languages.German:     {"der ", "die ", "das ", "ein ", "eine ", "sich "},
+	languages.Italian:    {"il ", "lo ", "la ", "i ", "gli ", "le ", "l'", "un ", "uno ", "una "},
+	languages.Portuguese: {"o ", "a ", "os ", "as ", "um ", "uma "},
+	languages.Dutch:      {"de ", "het ", "een "},
+}
+
+// NormalizeTerm folds a term to a comparison key: lower case, no accents, no leading article, single spaces
+func NormalizeTerm(term string, lang languages.Code) string {
+	term = strings.Join(strings.Fields(strings.ToLower(term)), " ")
+	term = strings.ReplaceAll(term, "’", "'")
+	for _, article := range leadingArticles[lang] {
+		if rest, ok := strings.CutPrefix(term, article); ok && rest != "" {
+			term = rest
+			break
+		}
+	}
+
+	folded, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), term)
+	if err != nil {
+		return term
+	}
+	return folded
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"github.com/marycka9/go-reverso-api/repositories"
+	"github.com/marycka9/go-reverso-api/usecases"
+	log "github.com/sirupsen/logrus"
+)
+
+// convert joins the per-language vocabulary files into a parallel one, or splits a parallel file into them
+func main() {
+	logger := log.New()
+
+	parallelPath := flag.String("parallel", "", "Path to the parallel vocabulary file, one row per concept and one column per language")
+	split := flag.Bool("split", false, "Write the per-language files from the parallel one instead of joining them into it")
+	full := flag.Bool("full", false, "Write every column of the words with -split, instead of the term;pos;concept rows of the hand-written files")
+	frenchFilePath := flag.String("french", "", "Path to the French CSV file")
+	englishFilePath := flag.String("english", "", "Path to the English CSV file")
+	russianFilePath := flag.String("russian", "", "Path to the Russian CSV file")
+	wordFiles := flag.String("words", "", "Comma-separated language=path pairs for any other language, e.g. es=data/spanish.csv")
+	flag.Parse()
+
+	if *parallelPath == "" {
+		logger.Error("Error: -parallel must be provided")
+		flag.Usage()
+		return
+	}
+
+	filePaths := map[languages.Code]string{
+		languages.French:  *frenchFilePath,
+		languages.English: *englishFilePath,
+		languages.Russian: *russianFilePath,
+	}
+	for _, pair := range strings.Split(*wordFiles, ",") {
+		if pair == "" {
+			continue
+		}
+		name, filePath, ok := strings.Cut(pair, "=")
+		lang, err := languages.Lookup(name)
+		if !ok || err != nil {
+			logger.Errorf("Error: invalid -words entry %q", pair)
+			flag.Usage()
+			return
+		}
+		filePaths[lang.Code] = filePath
+	}
+
+	csvRepo := repositories.NewCSVRepository()
+	parallelRepo := repositories.NewParallelRepository()
+
+	if *split {
+		wordsByLanguage, report, err := parallelRepo.ReadWordsFromFile(*parallelPath)
+		if err != nil {
+			logger.Fatal("Error reading parallel file:", err)
+			return
+		}
+		if len(report.Issues) > 0 {
+			logger.Warn(report.String())
+		}
+		for code, words := range wordsByLanguage {
+			// Languages without a path go next to the parallel file, named like data/french.csv
+			filePath := filePaths[code]
+			if filePath == "" {
+				filePath = filepath.Join(filepath.Dir(*parallelPath), languages.MustGet(code).Name+".csv")
+			}
+			save := csvRepo.SaveLegacy
+			if *full {
+				save = csvRepo.Save
+			}
+			if err := save(filePath, words); err != nil {
+				logger.Fatalf("Error writing %s words: %s", code, err)
+				return
+			}
+			logger.Infof("%d %s words written to %s", len(words), code, filePath)
+		}
+		return
+	}
+
+	wordsByLanguage := make(map[languages.Code][]entities.Word)
+	for code, filePath := range filePaths {
+		if filePath == "" {
+			continue
+		}
+		words, report, err := csvRepo.ReadWordsFromFile(filePath, code)
+		if err != nil {
+			logger.Fatalf("Error reading %s words: %s", code, err)
+			return
+		}
+		if len(report.Issues) > 0 {
+			logger.Warn(report.String())
+		}
+		wordsByLanguage[code] = words
+	}
+
+	// Linking completes the translations of the words, the concept column gives the words of a concept the same row
+	words, linkReport := usecases.NewWordTranslator().TranslateWords(wordsByLanguage)
+	if len(linkReport.Ambiguous) > 0 {
+		logger.Warn(linkReport.String())
+	}
+	if err := parallelRepo.Save(*parallelPath, words); err != nil {
+		logger.Fatal("Error writing parallel file:", err)
+		return
+	}
+	logger.Infof("%d words written to %s, %d of them linked", len(words), *parallelPath, linkReport.Linked)
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/atselvan/ankiconnect"
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"github.com/marycka9/go-reverso-api/repositories"
+	"github.com/marycka9/go-reverso-api/usecases"
+)
+
+// sourceAnki is the source of the pipeline stage writing to Anki. The dictionaries are capped by the translation
+// service, per source actually called, since a fallback chain can reach any of them
+const sourceAnki = "anki"
+
+// cardBuilder enriches words and turns them into Anki notes, recording its progress in the journal
+type cardBuilder struct {
+	translationService *usecases.TranslationService
+	anki               *repositories.AnkiRepository
+	journal            *repositories.JournalRepository
+	store              *repositories.VocabularyStore
+	target             *languages.Language
+	audioDir           string // directory of the pronunciations, none are fetched when empty
+	retryFailed        bool   // process only the words the journal records as failed
+}
+
+func newCardBuilder(translationService *usecases.TranslationService, anki *repositories.AnkiRepository, journal *repositories.JournalRepository, store *repositories.VocabularyStore, target *languages.Language, audioDir string, retryFailed bool) *cardBuilder {
+	return &cardBuilder{
+		translationService: translationService,
+		anki:               anki,
+		journal:            journal,
+		store:              store,
+		target:             target,
+		audioDir:           audioDir,
+		retryFailed:        retryFailed,
+	}
+}
+
+// stages returns the enrichment stages, in the order they run on a word
+func (b *cardBuilder) stages() []usecases.EnrichmentStage {
+	return []usecases.EnrichmentStage{
+		{Name: "journal", Run: b.resume},
+		{Name: "additional data", Run: b.additionalData},
+		{Name: "translations", Run: b.translations},
+		{Name: "gender", Run: b.gender},
+		{Name: "conjugation", Run: b.conjugation},
+		{Name: "enriched", Run: b.enriched},
+		{Name: "pronunciation", Run: b.pronunciation},
+		{Name: "anki", Source: sourceAnki, Run: b.notes},
+	}
+}
+
+// resume skips the words already done and restores the ones enriched by an earlier run. Retrying failed words
+// also retries the pending ones, an earlier run stopped before finishing them
+func (b *cardBuilder) resume(_ context.Context, word *entities.Word) error {
+	word.ID = word.Key()
+	entry, _ := b.journal.Get(word.ID)
+	switch {
+	case b.retryFailed && entry.State != entities.JournalFailed && entry.State != entities.JournalPending:
+		return usecases.ErrSkipWord
+	case entry.State == entities.JournalCardCreated:
+		return usecases.ErrSkipWord
+	case entry.State == entities.JournalEnriched && entry.Word != nil:
+		*word = *entry.Word
+		return nil
+	}
+
+	entry.Key = word.ID
+	entry.State = entities.JournalPending
+	entry.Attempts++
+	entry.Error = ""
+	entry.Warnings = nil
+	return b.journal.Record(entry)
+}
+
+// done reports whether the word was restored from the journal, its enrichment stages are then skipped
+func (b *cardBuilder) done(word *entities.Word) bool {
+	entry, _ := b.journal.Get(word.Key())
+	return entry.State == entities.JournalEnriched
+}
+
+func (b *cardBuilder) enriched(_ context.Context, word *entities.Word) error {
+	if b.done(word) {
+		return nil
+	}
+	entry, _ := b.journal.Get(word.Key())
+	enriched := *word
+	entry.State = entities.JournalEnriched
+	entry.Word = &enriched
+	return b.journal.Record(entry)
+}
+
+// skipped records a word a stage skipped after resume marked it pending as failed, so a retry picks it up
+// instead of leaving it pending
+func (b *cardBuilder) skipped(word *entities.Word, stage string) error {
+	entry, ok := b.journal.Get(word.Key())
+	if !ok || entry.State != entities.JournalPending {
+		return nil
+	}
+	return b.failed(word, fmt.Errorf("skipped by the %s stage", stage))
+}
+
+// failed records the error of a word that went through the pipeline
+func (b *cardBuilder) failed(word *entities.Word, err error) error {
+	entry, _ := b.journal.Get(word.Key())
+	entry.Key = word.Key()
+	entry.State = entities.JournalFailed
+	entry.Error = err.Error()
+	return b.journal.Record(entry)
+}
+
+// warned records the errors of the sources that failed for a word another source still enriched
+func (b *cardBuilder) warned(word *entities.Word, warnings []error) error {
+	entry, _ := b.journal.Get(word.Key())
+	entry.Key = word.Key()
+	entry.Warnings = make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		entry.Warnings = append(entry.Warnings, warning.Error())
+	}
+	return b.journal.Record(entry)
+}
+
+func (b *cardBuilder) additionalData(_ context.Context, word *entities.Word) error {
+	if b.done(word) {
+		return nil
+	}
+	err := b.translationService.GetAdditionalDataWithFallback(word)
+	if errors.Is(err, usecases.ErrNoResult) {
+		// No dictionary handles the language or knows more of the word, it keeps what the file gave
+		return nil
+	}
+	return err
+}
+
+// gender looks the gender of nouns the dictionaries did not know up in the Context entries of their translations
+func (b *cardBuilder) gender(_ context.Context, word *entities.Word) error {
+	if b.done(word) || word.Morphology.PartOfSpeech != entities.PosNoun || word.Morphology.Gender != entities.GenderNone {
+		return nil
+	}
+	return b.translationService.GetAdditionalData(usecases.REVERSO, word)
+}
+
+// translations fetches the translations into the target language. Words of the target language have none to fetch,
+// their cards show the words they are linked to
+func (b *cardBuilder) translations(_ context.Context, word *entities.Word) error {
+	if b.done(word) || word.Language == b.target.Code || word.Sources[entities.TranslationsField(b.target.Code)] == entities.SourceFile {
+		return nil
+	}
+	src, err := languages.Get(word.Language)
+	if err != nil {
+		return err
+	}
+	return b.translationService.GetTranslationsWithFallback(word, src, b.target)
+}
+
+func (b *cardBuilder) conjugation(_ context.Context, word *entities.Word) error {
+	if b.done(word) || word.Morphology.PartOfSpeech != entities.PosVerb {
+		return nil
+	}
+	if word.Language != languages.French {
+		// TODO: implement the addition of verb conjugation
+		return nil
+	}
+	verb, err := b.translationService.GetConjugation(usecases.REVERSO, word.Term, languages.MustGet(languages.French))
+	if err != nil {
+		return err
+	}
+	word.Conjugation = verb
+	return nil
+}
+
+// pronunciation saves a recording of the word under audioDir and records it in the vocabulary store, once per word
+func (b *cardBuilder) pronunciation(_ context.Context, word *entities.Word) error {
+	if b.audioDir == "" {
+		return nil
+	}
+	if entry, ok := b.store.Get(word.Key()); ok && len(entry.Audio) > 0 {
+		return nil
+	}
+	lang, err := languages.Get(word.Language)
+	if err != nil {
+		return err
+	}
+	path, _, err := b.translationService.GetPronunciationWithFallback(word.Term, lang, b.audioDir)
+	if errors.Is(err, usecases.ErrNoResult) {
+		return nil
+	}
+	if err != nil && !errors.Is(err, usecases.ErrPartial) {
+		return err
+	}
+	// The recording belongs to an entry, the word is stored before the run ends with it
+	if err := b.store.SaveWord(*word); err != nil {
+		return err
+	}
+	if err := b.store.AddAudio(word.Key(), entities.Pronunciation{IPA: strings.Trim(word.Transcription, "/"), Path: path}); err != nil {
+		return err
+	}
+	// The sources that failed before another one answered are reported with the word
+	return err
+}
+
+// notes adds the notes of the word to Anki, or updates the ones an earlier run added when the word changed.
+// The ID of every note is recorded as soon as it is saved: a retry finds the notes already added by their key tag
+// and does not add them again
+func (b *cardBuilder) notes(_ context.Context, word *entities.Word) error {
+	var errs []error
+	for _, note := range b.buildNotes(word) {
+		_, ids, err := b.anki.Save(note, word.Tag())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		entry, _ := b.journal.Get(word.Key())
+		if added := slices.DeleteFunc(ids, func(id int64) bool { return slices.Contains(entry.NoteIDs, id) }); len(added) > 0 {
+			entry.NoteIDs = append(entry.NoteIDs, added...)
+			if err := b.journal.Record(entry); err != nil {
+				return err
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	entry, _ := b.journal.Get(word.Key())
+	entry.State = entities.JournalCardCreated
+	return b.journal.Record(entry)
+}
+
+// backTranslations returns the translations into the target language, or those into every other language for
+// the words of the target language
+func (b *cardBuilder) backTranslations(word *entities.Word) []string {
+	if word.Language != b.target.Code {
+		return word.Translations[b.target.Code]
+	}
+	codes := make([]languages.Code, 0, len(word.Translations))
+	for code := range word.Translations {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	res := make([]string, 0)
+	for _, code := range codes {
+		res = append(res, word.Translations[code]...)
+	}
+	return res
+}
+
+// genderColors are the colors nouns are written in on the cards, by gender
+var genderColors = map[entities.Gender]string{
+	entities.GenderMasculine:         "#1565c0",
+	entities.GenderFeminine:          "#c62828",
+	entities.GenderNeuter:            "#2e7d32",
+	entities.GenderMasculineFeminine: "#6a1b9a",
+}
+
+// front returns the term as written on the card: nouns come with their article, in the color of their gender
+func front(word *entities.Word) string {
+	term := strings.TrimSpace(fmt.Sprintf("%s %s", word.TermWithArticle(), word.TermAlt))
+	if color, ok := genderColors[word.Morphology.Gender]; ok {
+		return fmt.Sprintf(`<span style="color: %s">%s</span>`, color, term)
+	}
+	return term
+}
+
+// buildNotes returns the notes of the word: its conjugation, a correction note when Larousse returned several
+// transcriptions, and the word itself
+func (b *cardBuilder) buildNotes(word *entities.Word) []ankiconnect.Note {
+	deck, correctionDeck := "English_words", "English_words_need_work"
+	if word.Language == languages.French {
+		deck, correctionDeck = "Francais_mots", "Francais_mots_corriger"
+	}
+	if word.Deck != "" {
+		deck = word.Deck
+	}
+	kind := word.Morphology.String()
+	back := strings.Join(b.backTranslations(word), "<br>")
+	for _, example := range word.Examples {
+		back += "<br><i>" + example.Source + "</i>"
+	}
+	if word.Notes != "" {
+		back += "<br><small>" + word.Notes + "</small>"
+	}
+
+	tags := append([]string{word.Tag()}, word.Tags...)
+	notes := make([]ankiconnect.Note, 0, 3)
+	if verb := word.Conjugation; verb != nil {
+		notes = append(notes, ankiconnect.Note{
+			DeckName:  "Francais_conjugation",
+			ModelName: "Basic (de conjugaison A1)",
+			Fields: ankiconnect.Fields{
+				"Infinitif": verb.Infinitif,
+				"Présent":   strings.Join(verb.Indicatif["Présent"], "<br>"),
+				"Impératif": strings.Join(verb.Imperatif["Présent"], "<br>"),
+			},
+			Tags: tags,
+		})
+	}
+	if strings.IndexRune(word.Transcription, ',') != -1 && word.TermAlt == "" {
+		notes = append(notes, ankiconnect.Note{
+			DeckName:  correctionDeck,
+			ModelName: "Basic (and reversed card french)",
+			Fields: ankiconnect.Fields{
+				"Front": strings.Join([]string{fmt.Sprintf("%s %s", word.Term, "ERROR"), word.Transcription, kind}, "<br>"),
+				"Back":  back,
+			},
+			Tags: tags,
+		})
+	}
+	notes = append(notes, ankiconnect.Note{
+		DeckName:  deck,
+		ModelName: "Basic (and reversed card french)",
+		Fields: ankiconnect.Fields{
+			"Front": strings.Join([]string{front(word), word.Transcription, kind}, "<br>"),
+			"Back":  back,
+		},
+		Tags: tags,
+	})
+	return notes
+}
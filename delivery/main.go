@@ -1,16 +1,21 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/atselvan/ankiconnect"
 	"github.com/marycka9/go-reverso-api/client"
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
 	"github.com/marycka9/go-reverso-api/languages"
 	"github.com/marycka9/go-reverso-api/repositories"
 	"github.com/marycka9/go-reverso-api/usecases"
 	log "github.com/sirupsen/logrus"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
 func main() {
@@ -20,48 +25,118 @@ func main() {
 	frenchFilePath := flag.String("french", "", "Path to the French CSV file")
 	englishFilePath := flag.String("english", "", "Path to the English CSV file")
 	russianFilePath := flag.String("russian", "", "Path to the Russian CSV file")
+	workers := flag.Int("workers", 4, "Number of words enriched at once")
+	journalPath := flag.String("journal", "import.journal.jsonl", "Path to the journal recording the progress of every word")
+	compact := flag.Bool("compact", false, "Rewrite the journal and the -store with the last state of every word, then exit")
+	retryFailed := flag.Bool("retry-failed", false, "Process only the words the journal records as failed")
+	parallelPath := flag.String("parallel", "", "Path to a parallel vocabulary file, one row per concept and one column per language")
+	wordFiles := flag.String("words", "", "Comma-separated language=path pairs for any other language, e.g. es=data/spanish.csv")
+	delimiter := flag.String("delimiter", "", "Field delimiter of the CSV files, guessed when empty; use \"tab\" for tabs")
+	encoding := flag.String("encoding", repositories.EncodingAuto, "Encoding of the CSV files: utf-8, utf-16 or windows-1252, detected when empty")
+	posVocabulary := flag.String("pos-vocabulary", "", "Path to a JSON file of extra part of speech labels, in the format of common/part_of_speech.json")
+	savePath := flag.String("save", "", "Path to a .csv, .tsv, .jsonl or .yaml file the enriched words are written to for review")
+	dryRun := flag.Bool("dry-run", false, "Enrich the words without creating the Anki notes, to review them with -save first")
+	reviewedPath := flag.String("reviewed", "", "Path to a file written by -save, its words go to Anki as they are, without enrichment")
+	ankiURL := flag.String("anki", "http://localhost:8765", "URL of the AnkiConnect API")
+	storePath := flag.String("store", "data/vocabulary.store.jsonl", "Path to the vocabulary store keeping every enriched word with its recordings and Anki notes")
+	selectQuery := flag.String("select", "", "Comma-separated filters of the -store words sent to Anki as they are, e.g. language=fr,pos=noun,tag=food,since=2024-01-31; \"all\" selects every word")
+	forgetQuery := flag.String("forget", "", "Comma-separated filters, as for -select, of the words removed from the -store")
+	audioDir := flag.String("audio", "", "Directory the pronunciations of the words are saved to, none are fetched when empty")
 	flag.Parse()
 
-	// Checking for mandatory flags
-	if *frenchFilePath == "" || *englishFilePath == "" || *russianFilePath == "" {
-		logger.Error("Error: all file paths must be provided")
-		flag.Usage()
-		return
+	if *posVocabulary != "" {
+		if err := common.GetPartOfSpeechParserInstance().LoadVocabularyFile(*posVocabulary); err != nil {
+			logger.Fatal("Error loading part of speech vocabulary:", err)
+			return
+		}
 	}
-	// Repositories
-	csvRepo := repositories.NewCSVRepository()
 
-	// Read data from CSV files
-	frenchWords, err := csvRepo.ReadWordsFromFile(*frenchFilePath, entities.French)
-	if err != nil {
-		logger.Fatal("Error reading French words:", err)
+	if *compact {
+		if err := compactJournal(*journalPath); err != nil {
+			logger.Fatal("Error compacting journal:", err)
+		}
+		if err := compactStore(*storePath); err != nil {
+			logger.Fatal("Error compacting vocabulary store:", err)
+		}
 		return
 	}
 
-	englishWords, err := csvRepo.ReadWordsFromFile(*englishFilePath, entities.English)
-	if err != nil {
-		logger.Fatal("Error reading English words:", err)
-		return
+	filePaths := map[languages.Code]string{
+		languages.French:  *frenchFilePath,
+		languages.English: *englishFilePath,
+		languages.Russian: *russianFilePath,
+	}
+	for _, pair := range strings.Split(*wordFiles, ",") {
+		if pair == "" {
+			continue
+		}
+		name, filePath, ok := strings.Cut(pair, "=")
+		lang, err := languages.Lookup(name)
+		if !ok || err != nil {
+			logger.Errorf("Error: invalid -words entry %q", pair)
+			flag.Usage()
+			return
+		}
+		filePaths[lang.Code] = filePath
 	}
 
-	russianWords, err := csvRepo.ReadWordsFromFile(*russianFilePath, entities.Russian)
-	if err != nil {
-		logger.Fatal("Error reading Russian words:", err)
+	// Checking for mandatory flags
+	for code, filePath := range filePaths {
+		if filePath == "" {
+			delete(filePaths, code)
+		}
+	}
+	if len(filePaths) == 0 && *parallelPath == "" && *reviewedPath == "" && *selectQuery == "" && *forgetQuery == "" {
+		logger.Error("Error: at least one file path must be provided")
+		flag.Usage()
 		return
 	}
+	// Repositories
+	csvOptions := repositories.CSVOptions{Encoding: *encoding}
+	switch *delimiter {
+	case "":
+	case "tab", `\t`:
+		csvOptions.Delimiter = '\t'
+	default:
+		csvOptions.Delimiter, _ = utf8.DecodeRuneInString(*delimiter)
+	}
+	csvRepo := repositories.NewCSVRepositoryWithOptions(csvOptions)
+
+	// Read data from CSV files
+	wordsByLanguage := make(map[languages.Code][]entities.Word, len(filePaths))
+	for code, filePath := range filePaths {
+		words, report, err := csvRepo.ReadWordsFromFile(filePath, code)
+		if err != nil {
+			logger.Fatalf("Error reading %s words: %s", code, err)
+			return
+		}
+		if len(report.Issues) > 0 {
+			logger.Warn(report.String())
+		}
+		wordsByLanguage[code] = words
+	}
+	if *parallelPath != "" {
+		parallelWords, report, err := repositories.NewParallelRepositoryWithOptions(csvOptions).ReadWordsFromFile(*parallelPath)
+		if err != nil {
+			logger.Fatal("Error reading parallel words:", err)
+			return
+		}
+		if len(report.Issues) > 0 {
+			logger.Warn(report.String())
+		}
+		for code, words := range parallelWords {
+			wordsByLanguage[code] = append(wordsByLanguage[code], words...)
+		}
+	}
 
 	// UseCases
 	wordTranslator := usecases.NewWordTranslator()
 
-	// Aggregate all words by language
-	wordsByLanguage := map[entities.Language][]entities.Word{
-		entities.French:  frenchWords,
-		entities.English: englishWords,
-		entities.Russian: russianWords,
-	}
-
 	// Translate words between languages
-	translatedWords := wordTranslator.TranslateWords(wordsByLanguage)
+	translatedWords, linkReport := wordTranslator.TranslateWords(wordsByLanguage)
+	if len(wordsByLanguage) > 1 && (len(linkReport.Unlinked) > 0 || len(linkReport.Ambiguous) > 0) {
+		logger.Warn(linkReport.String())
+	}
 
 	// Initialize clients
 	reversoContextClient := client.NewClient()
@@ -69,117 +144,246 @@ func main() {
 	larousseScarper := repositories.NewLarousseScarping()
 
 	// Register parsers in the service
-	translationService := usecases.NewTranslationService(map[usecases.TranslationServiceType]repositories.TranslationFetcher{
+	translationService := usecases.NewTranslationService(map[usecases.TranslationServiceType]repositories.Source{
 		usecases.REVERSO:   reversoContextClient,
 		usecases.CAMBRIDGE: dictionaryCambridgeParser,
 		usecases.LAROUSSE:  larousseScarper,
 	})
 
-	langs := languages.GetLanguages()
-	// Display the translated words
-	for _, word := range translatedWords {
-		if word.Language == entities.French {
-			if err := translationService.GetAdditionalData(usecases.LAROUSSE, &word); err != nil {
-				log.Error("Error FetchAdditionalData", err)
-				continue
-			}
-			if err := translationService.GetTranslations(usecases.REVERSO, &word, langs[string(entities.French)], langs[string(entities.Russian)]); err != nil {
-				log.Error("Error GetTranslations", err)
-				continue
-			}
-			ankiClient := ankiconnect.NewClient()
-			if word.PartOfSpeech == "v" {
-				verb, err := reversoContextClient.FetchConjugation(word.Term, word.Language)
-				if err != nil {
-					log.Error("Error FetchConjugation", err)
-					continue
-				}
-				log.Infof("Conjugation: %s", verb)
-				note := ankiconnect.Note{
-					DeckName:  "Francais_conjugation",
-					ModelName: "Basic (de conjugaison A1)",
-					Fields: ankiconnect.Fields{
-						"Infinitif": verb.Infinitif,
-						"Présent":   strings.Join(verb.Indicatif["Présent"], "<br>"),
-						"Impératif": strings.Join(verb.Imperatif["Présent"], "<br>"),
-					},
-				}
-				restErr := ankiClient.Notes.Add(note)
-				if restErr != nil {
-					log.Error(restErr)
-				}
-			}
-			if strings.IndexRune(word.Transcription, ',') != -1 && word.TermAlt == "" {
-				note := ankiconnect.Note{
-					DeckName:  "Francais_mots_corriger",
-					ModelName: "Basic (and reversed card french)",
-					Fields: ankiconnect.Fields{
-						"Front": strings.Join([]string{fmt.Sprintf("%s %s", word.Term, "ERROR"), word.Transcription, word.Type}, "<br>"),
-						"Back":  strings.Join(word.Translations["ru"], "<br>"),
-					},
-				}
-				restErr := ankiClient.Notes.Add(note)
-				if restErr != nil {
-					log.Error(restErr)
-				}
-			}
-			note := ankiconnect.Note{
-				DeckName:  "Francais_mots",
-				ModelName: "Basic (and reversed card french)",
-				Fields: ankiconnect.Fields{
-					"Front": strings.Join([]string{fmt.Sprintf("%s %s", word.Term, word.TermAlt), word.Transcription, word.Type}, "<br>"),
-					"Back":  strings.Join(word.Translations["ru"], "<br>"),
-				},
-			}
-			restErr := ankiClient.Notes.Add(note)
-			if restErr != nil {
-				log.Error(restErr)
-			}
+	journal, err := repositories.OpenJournalRepository(*journalPath)
+	if err != nil {
+		logger.Fatal("Error opening journal:", err)
+		return
+	}
+	defer journal.Close()
 
-		} else {
-			if err := translationService.GetTranslations(usecases.REVERSO, &word, langs[string(entities.English)], langs[string(entities.Russian)]); err != nil {
-				log.Error("Error GetTranslations", err)
-				continue
+	if *reviewedPath != "" {
+		reviewed, err := loadReviewed(*reviewedPath, journal)
+		if err != nil {
+			logger.Fatal("Error reading reviewed words:", err)
+			return
+		}
+		translatedWords = append(translatedWords, reviewed...)
+	}
+
+	store, err := repositories.OpenVocabularyStore(*storePath)
+	if err != nil {
+		logger.Fatal("Error opening vocabulary store:", err)
+		return
+	}
+	defer store.Close()
+	if *forgetQuery != "" {
+		query, err := parseQuery(*forgetQuery)
+		if err != nil {
+			logger.Fatal("Error: invalid -forget:", err)
+			return
+		}
+		forgotten := store.Find(query)
+		for _, entry := range forgotten {
+			if err := store.Delete(entry.Key); err != nil {
+				logger.Fatal("Error removing word from vocabulary store:", err)
+				return
 			}
-			ankiClient := ankiconnect.NewClient()
-			if word.PartOfSpeech == "v" {
-				// TODO: implement the addition of verb conjugation
-				log.Infof("implement the addition of verb conjugation")
-				//reversoContextClient.FetchConjugation()
+		}
+		logger.Infof("%d words removed from %s", len(forgotten), *storePath)
+	}
+	if *selectQuery != "" {
+		query, err := parseQuery(*selectQuery)
+		if err != nil {
+			logger.Fatal("Error: invalid -select:", err)
+			return
+		}
+		selected := store.Words(query)
+		if err := markEnriched(selected, journal); err != nil {
+			logger.Fatal("Error recording selected words in journal:", err)
+			return
+		}
+		translatedWords = append(translatedWords, selected...)
+	}
+
+	var saveRepo repositories.WordRepository
+	if *savePath != "" {
+		if saveRepo, err = repositories.NewWordRepository(*savePath); err != nil {
+			logger.Fatal("Error:", err)
+			return
+		}
+	}
+
+	anki := repositories.NewAnkiRepository(ankiconnect.NewClient().SetURL(*ankiURL))
+	cards := newCardBuilder(translationService, anki, journal, store, languages.MustGet(languages.Russian), *audioDir, *retryFailed)
+	stages := cards.stages()
+	if *dryRun {
+		stages = slices.DeleteFunc(stages, func(stage usecases.EnrichmentStage) bool { return stage.Source == sourceAnki })
+	}
+	translationService.SetConcurrencyLimit(usecases.REVERSO, 4)
+	translationService.SetConcurrencyLimit(usecases.LAROUSSE, 2)
+	translationService.SetConcurrencyLimit(usecases.CAMBRIDGE, 2)
+	pipeline := usecases.NewEnrichmentPipeline(usecases.PipelineConfig{
+		Workers:      *workers,
+		SourceLimits: map[string]int{sourceAnki: 1},
+		OnProgress: func(progress usecases.PipelineProgress) {
+			logger.Infof("%d/%d words: %d failed, %d skipped, ETA %s",
+				progress.Done+progress.Failed+progress.Skipped, progress.Total, progress.Failed, progress.Skipped, progress.ETA.Round(time.Second))
+		},
+	}, stages...)
+
+	// Display the translated words
+	enriched := make([]entities.Word, 0, len(translatedWords))
+	report := pipeline.Run(context.Background(), translatedWords, func(result usecases.PipelineResult) {
+		word := result.Word
+		for _, warning := range result.Warnings {
+			logger.Warnf("[%s] %s: %s", word.Language, word.Term, warning)
+		}
+		if len(result.Warnings) > 0 {
+			if err := cards.warned(&word, result.Warnings); err != nil {
+				logger.Error("Error recording warnings in journal:", err)
 			}
-			if strings.IndexRune(word.Transcription, ',') != -1 && word.TermAlt == "" {
-				note := ankiconnect.Note{
-					DeckName:  "English_words_need_work",
-					ModelName: "Basic (and reversed card french)",
-					Fields: ankiconnect.Fields{
-						"Front": strings.Join([]string{fmt.Sprintf("%s %s", word.Term, "ERROR"), word.Transcription, word.Type}, "<br>"),
-						"Back":  strings.Join(word.Translations["ru"], "<br>"),
-					},
-				}
-				restErr := ankiClient.Notes.Add(note)
-				if restErr != nil {
-					log.Error(restErr)
-				}
+		}
+		if result.Err != nil {
+			logger.Errorf("[%s] %s: %s", word.Language, word.Term, result.Err)
+			if err := cards.failed(&word, result.Err); err != nil {
+				logger.Error("Error recording failure in journal:", err)
 			}
-			note := ankiconnect.Note{
-				DeckName:  "English_words",
-				ModelName: "Basic (and reversed card french)",
-				// TODO: convert word.type and word.PartOfSpeech to the same variable
-				Fields: ankiconnect.Fields{
-					"Front": strings.Join([]string{fmt.Sprintf("%s %s", word.Term, word.TermAlt), word.Transcription, word.PartOfSpeech}, "<br>"),
-					"Back":  strings.Join(word.Translations["ru"], "<br>"),
-				},
+			return
+		}
+		if result.Skipped {
+			if err := cards.skipped(&word, result.Stage); err != nil {
+				logger.Error("Error recording skipped word in journal:", err)
 			}
-			restErr := ankiClient.Notes.Add(note)
-			if restErr != nil {
-				log.Error(restErr)
+		}
+		entry, _ := journal.Get(word.Key())
+		if result.Skipped {
+			// Words done by an earlier run are saved as the journal kept them
+			if entry.Word == nil {
+				return
 			}
-
+			word = *entry.Word
+		}
+		enriched = append(enriched, word)
+		if err := storeWord(store, word, entry.NoteIDs); err != nil {
+			logger.Error("Error saving word to vocabulary store:", err)
 		}
-		logger.Infof("[%s] %s %s (%s) %s\n", word.Language, word.Term, word.TermAlt, word.PartOfSpeech, word.Transcription)
+		if result.Skipped {
+			return
+		}
+		logger.Infof("[%s] %s %s (%s) %s\n", word.Language, word.Term, word.TermAlt, word.Morphology, word.Transcription)
 		for k, v := range word.Translations {
-			log.Infof(" [%s] (%s)\n", k, v)
+			log.Infof(" [%s] (%s) from %s\n", k, v, word.Sources[entities.TranslationsField(k)])
+		}
+	})
+
+	logger.Info(report.String())
+	for stage, spent := range report.StageDurations {
+		logger.Infof(" %s: %s", stage, spent.Round(time.Millisecond))
+	}
+	if !*dryRun {
+		logger.Info(anki.Report().String())
+	}
+
+	if saveRepo != nil {
+		if err := saveRepo.Save(*savePath, enriched); err != nil {
+			logger.Fatal("Error saving the enriched words:", err)
+			return
+		}
+		logger.Infof("%d enriched words saved to %s", len(enriched), *savePath)
+	}
+}
+
+// compactJournal drops the history of the states of the words from the journal at filePath
+func compactJournal(filePath string) error {
+	journal, err := repositories.OpenJournalRepository(filePath)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+	return journal.Compact()
+}
+
+// compactStore drops the history of the changes of the words from the vocabulary store at filePath
+func compactStore(filePath string) error {
+	store, err := repositories.OpenVocabularyStore(filePath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	return store.Compact()
+}
+
+// loadReviewed reads the words of a file written by -save, see markEnriched
+func loadReviewed(filePath string, journal *repositories.JournalRepository) ([]entities.Word, error) {
+	repo, err := repositories.NewWordRepository(filePath)
+	if err != nil {
+		return nil, err
+	}
+	words, err := repo.Load(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return words, markEnriched(words, journal)
+}
+
+// markEnriched records the words in the journal as enriched, so the pipeline sends them to Anki as they are.
+// The notes of the words already sent are updated
+func markEnriched(words []entities.Word, journal *repositories.JournalRepository) error {
+	for i := range words {
+		words[i].ID = words[i].Key()
+		word := words[i]
+		entry, _ := journal.Get(word.ID)
+		entry.Key = word.ID
+		entry.State = entities.JournalEnriched
+		entry.Word = &word
+		if err := journal.Record(entry); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+// storeWord saves the word and the Anki notes the journal recorded for it to the vocabulary store
+func storeWord(store *repositories.VocabularyStore, word entities.Word, noteIDs []int64) error {
+	if err := store.SaveWord(word); err != nil {
+		return err
+	}
+	if entry, _ := store.Get(word.Key()); len(noteIDs) == 0 || slices.Equal(entry.NoteIDs, noteIDs) {
+		return nil
+	}
+	return store.SetNoteIDs(word.Key(), noteIDs)
+}
 
+// parseQuery reads the filters of -select: language, pos, tag, since and before, dates being written 2006-01-02
+func parseQuery(value string) (entities.VocabularyQuery, error) {
+	query := entities.VocabularyQuery{}
+	if value == "all" {
+		return query, nil
+	}
+	for _, filter := range strings.Split(value, ",") {
+		name, arg, ok := strings.Cut(strings.TrimSpace(filter), "=")
+		if !ok || arg == "" {
+			return query, fmt.Errorf("filter %q is not name=value", filter)
+		}
+		var err error
+		switch name {
+		case "language", "lang":
+			var lang *languages.Language
+			if lang, err = languages.Lookup(arg); err == nil {
+				query.Language = lang.Code
+			}
+		case "pos":
+			if query.PartOfSpeech = entities.ParseMorphology(arg).PartOfSpeech; query.PartOfSpeech == entities.PosUnknown {
+				err = fmt.Errorf("unknown part of speech %q", arg)
+			}
+		case "tag":
+			query.Tag = arg
+		case "since":
+			query.AddedAfter, err = time.Parse(time.DateOnly, arg)
+		case "before":
+			query.AddedBefore, err = time.Parse(time.DateOnly, arg)
+		default:
+			err = fmt.Errorf("unknown filter %q", name)
+		}
+		if err != nil {
+			return query, err
+		}
 	}
+	return query, nil
 }
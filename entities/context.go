@@ -1,8 +1,10 @@
 package entities
 
 import (
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/languages"
 	"net/url"
+	"slices"
 	"strconv"
 )
 
@@ -36,8 +38,8 @@ type ContextRequest struct {
 func NewContextRequest(text string, srcLang, dstLang *languages.Language, page int) *ContextRequest {
 	return &ContextRequest{
 		SourceText: text,
-		SourceLang: srcLang.Code,
-		TargetLang: dstLang.Code,
+		SourceLang: string(srcLang.Code),
+		TargetLang: string(dstLang.Code),
 		Npage:      page,
 		Nrows:      4,
 		ExprSug:    1,
@@ -77,6 +79,27 @@ type DictionaryEntryList struct {
 	InflectedForms   []DictionaryEntryList `json:"inflectedForms"`
 }
 
+// PartsOfSpeech returns the normalized parts of speech of the entry
+func (d DictionaryEntryList) PartsOfSpeech() []string {
+	if d.Pos == nil {
+		return nil
+	}
+	return common.GetPartOfSpeechParserInstance().ParseAllFor(*d.Pos, "", common.VocabularyReverso)
+}
+
+// HasPartOfSpeech reports whether the entry is the normalized part of speech
+func (d DictionaryEntryList) HasPartOfSpeech(partOfSpeech string) bool {
+	return slices.Contains(d.PartsOfSpeech(), partOfSpeech)
+}
+
+// Morphology returns the morphology the entry is tagged with, such as "nm" or "vt"
+func (d DictionaryEntryList) Morphology() Morphology {
+	if d.Pos == nil {
+		return Morphology{}
+	}
+	return ParseMorphologyFor("", common.VocabularyReverso, *d.Pos)
+}
+
 type FuzzySuggestion struct {
 	Lang       string `json:"lang"`
 	Suggestion string `json:"suggestion"`
@@ -0,0 +1,35 @@
+package entities
+
+// DictionaryEntry is a headword of a dictionary page with all of its senses
+type DictionaryEntry struct {
+	Headword   string
+	Morphology Morphology
+	Plural     string // irregular plural form, when the dictionary gives it
+	Senses     []DictionarySense
+}
+
+// DictionarySense is one meaning of a dictionary entry
+type DictionarySense struct {
+	Guideword    string // short hint telling the senses apart, e.g. ANIMAL
+	Definition   string
+	Level        string // CEFR level label, A1 to C2, empty when the dictionary gives none
+	Translations []string
+	Examples     []ExamplePair
+}
+
+// AllTranslations returns the translations of every sense of the entries without duplicates, in page order
+func AllTranslations(entries []DictionaryEntry) []string {
+	res := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, sense := range entry.Senses {
+			for _, translation := range sense.Translations {
+				if !seen[translation] {
+					seen[translation] = true
+					res = append(res, translation)
+				}
+			}
+		}
+	}
+	return res
+}
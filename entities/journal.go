@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+// JournalState is how far an import got with a word
+type JournalState string
+
+// Journal states, in the order a word goes through them
+const (
+	JournalPending     JournalState = "pending"
+	JournalEnriched    JournalState = "enriched"
+	JournalCardCreated JournalState = "card_created"
+	JournalFailed      JournalState = "failed"
+)
+
+// JournalEntry is the state of a word in an import journal
+type JournalEntry struct {
+	Key       string       `json:"key"`
+	State     JournalState `json:"state"`
+	Word      *Word        `json:"word,omitempty"` // enriched word, kept to resume without fetching again
+	NoteIDs   []int64      `json:"note_ids,omitempty"`
+	Error     string       `json:"error,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty"` // errors of sources that failed while others answered
+	Attempts  int          `json:"attempts"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
@@ -0,0 +1,200 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/marycka9/go-reverso-api/common"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// PartOfSpeech is a normalized part of speech, the values are the ones of common.PartOfSpeechParser
+type PartOfSpeech string
+
+const (
+	PosNoun         PartOfSpeech = common.Noun
+	PosVerb         PartOfSpeech = common.Verb
+	PosAdjective    PartOfSpeech = common.Adjective
+	PosAdverb       PartOfSpeech = common.Adverb
+	PosConjunction  PartOfSpeech = common.Conjunction
+	PosPronoun      PartOfSpeech = common.Pronoun
+	PosPreposition  PartOfSpeech = common.Preposition
+	PosInterjection PartOfSpeech = common.Interjection
+	PosArticle      PartOfSpeech = common.Article
+	PosUnknown      PartOfSpeech = ""
+)
+
+// SubType refines a part of speech, e.g. a transitive verb or an uncountable noun
+type SubType string
+
+const (
+	SubTypeTransitive   SubType = common.Transitive
+	SubTypeIntransitive SubType = common.Intransitive
+	SubTypePronominal   SubType = common.Pronominal
+	SubTypePhrasal      SubType = common.Phrasal
+	SubTypeAuxiliary    SubType = common.Auxiliary
+	SubTypeImpersonal   SubType = common.Impersonal
+	SubTypeCountable    SubType = common.Countable
+	SubTypeUncountable  SubType = common.Uncountable
+)
+
+// Gender is the grammatical gender of a noun
+type Gender string
+
+const (
+	GenderNone              Gender = ""
+	GenderMasculine         Gender = common.Masculine
+	GenderFeminine          Gender = common.Feminine
+	GenderNeuter            Gender = common.Neuter
+	GenderMasculineFeminine Gender = common.MasculineFeminine
+)
+
+// Number is the grammatical number of a word, invariable words having the same form in both
+type Number string
+
+const (
+	NumberNone       Number = ""
+	NumberSingular   Number = common.Singular
+	NumberPlural     Number = common.Plural
+	NumberInvariable Number = common.Invariable
+)
+
+// Register is a usage label, such as informal or literary
+type Register string
+
+const (
+	RegisterFormal       Register = common.Formal
+	RegisterInformal     Register = common.Informal
+	RegisterSlang        Register = common.Slang
+	RegisterVulgar       Register = common.Vulgar
+	RegisterLiterary     Register = common.Literary
+	RegisterOldFashioned Register = common.OldFashioned
+	RegisterHumorous     Register = common.Humorous
+	RegisterSpecialized  Register = common.Specialized
+)
+
+// Morphology is the grammatical description of a word, the same whichever source it came from
+type Morphology struct {
+	PartOfSpeech PartOfSpeech `json:"pos" yaml:"pos"`
+	SubTypes     []SubType    `json:"sub_types,omitempty" yaml:"sub_types,omitempty"`
+	Gender       Gender       `json:"gender,omitempty" yaml:"gender,omitempty"`
+	Number       Number       `json:"number,omitempty" yaml:"number,omitempty"`
+	Registers    []Register   `json:"registers,omitempty" yaml:"registers,omitempty"`
+}
+
+// ParseMorphology reads labels in any language: a Larousse header type ("nom masculin", "verbe pronominal"),
+// the Cambridge part of speech and grammar codes ("noun", "[ U ]", "informal"), a Reverso tag ("nm", "vt") or
+// the part of speech column of our files
+func ParseMorphology(labels ...string) Morphology {
+	return ParseMorphologyFor("", "", labels...)
+}
+
+// ParseMorphologyFor reads labels with the part of speech vocabularies of the language and the source,
+// see common.PartOfSpeechParser
+func ParseMorphologyFor(lang languages.Code, source string, labels ...string) Morphology {
+	label := strings.Join(labels, " ")
+	morphology := Morphology{
+		Gender: Gender(common.ParseGender(label)),
+		Number: Number(common.ParseNumber(label)),
+	}
+	if partOfSpeech, err := common.GetPartOfSpeechParserInstance().ParseFor(label, lang, source); err == nil {
+		morphology.PartOfSpeech = PartOfSpeech(partOfSpeech)
+	}
+	for _, subType := range common.ParseSubTypes(label) {
+		morphology.SubTypes = append(morphology.SubTypes, SubType(subType))
+	}
+	for _, register := range common.ParseRegisters(label) {
+		morphology.Registers = append(morphology.Registers, Register(register))
+	}
+	return morphology
+}
+
+// Known reports whether the part of speech is known
+func (m Morphology) Known() bool {
+	return m.PartOfSpeech != PosUnknown
+}
+
+// HasSubType reports whether the word has the sub-type
+func (m Morphology) HasSubType(subType SubType) bool {
+	return slices.Contains(m.SubTypes, subType)
+}
+
+// Merge completes the morphology with what another source found. The other morphology is ignored when
+// it describes another part of speech, as dictionaries list homographs such as "le manger" and "manger"
+func (m *Morphology) Merge(other Morphology) {
+	if !m.Known() {
+		m.PartOfSpeech = other.PartOfSpeech
+	} else if other.Known() && other.PartOfSpeech != m.PartOfSpeech {
+		return
+	}
+
+	if m.Gender == GenderNone {
+		m.Gender = other.Gender
+	}
+	if m.Number == NumberNone {
+		m.Number = other.Number
+	}
+	for _, subType := range other.SubTypes {
+		if !slices.Contains(m.SubTypes, subType) {
+			m.SubTypes = append(m.SubTypes, subType)
+		}
+	}
+	for _, register := range other.Registers {
+		if !slices.Contains(m.Registers, register) {
+			m.Registers = append(m.Registers, register)
+		}
+	}
+}
+
+// Equal reports whether both morphologies describe the word the same way
+func (m Morphology) Equal(other Morphology) bool {
+	return m.PartOfSpeech == other.PartOfSpeech && m.Gender == other.Gender && m.Number == other.Number &&
+		slices.Equal(m.SubTypes, other.SubTypes) && slices.Equal(m.Registers, other.Registers)
+}
+
+// Readable names String uses for the values abbreviated in the model
+var (
+	posNames = map[PartOfSpeech]string{
+		PosNoun:         "noun",
+		PosVerb:         "verb",
+		PosAdjective:    "adjective",
+		PosAdverb:       "adverb",
+		PosConjunction:  "conjunction",
+		PosPronoun:      "pronoun",
+		PosPreposition:  "preposition",
+		PosInterjection: "interjection",
+		PosArticle:      "article",
+	}
+	genderNames = map[Gender]string{
+		GenderMasculine:         "masculine",
+		GenderFeminine:          "feminine",
+		GenderNeuter:            "neuter",
+		GenderMasculineFeminine: "masculine and feminine",
+	}
+	numberNames = map[Number]string{
+		NumberSingular:   "singular",
+		NumberPlural:     "plural",
+		NumberInvariable: "invariable",
+	}
+)
+
+// String describes the morphology in words, e.g. "verb transitive pronominal" or "noun feminine plural"
+func (m Morphology) String() string {
+	words := make([]string, 0, 4+len(m.SubTypes)+len(m.Registers))
+	if m.Known() {
+		words = append(words, posNames[m.PartOfSpeech])
+	}
+	for _, subType := range m.SubTypes {
+		words = append(words, string(subType))
+	}
+	if m.Gender != GenderNone {
+		words = append(words, genderNames[m.Gender])
+	}
+	if m.Number != NumberNone {
+		words = append(words, numberNames[m.Number])
+	}
+	for _, register := range m.Registers {
+		words = append(words, string(register))
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,42 @@
+package entities
+
+// Region tells which variety of the language a pronunciation belongs to
+type Region string
+
+const (
+	RegionUK Region = "uk"
+	RegionUS Region = "us"
+	// RegionNone is used by dictionaries giving a single pronunciation
+	RegionNone Region = ""
+)
+
+// Pronunciation is an IPA transcription with the recordings of it
+type Pronunciation struct {
+	Region   Region `json:"region,omitempty"`
+	IPA      string `json:"ipa"`
+	AudioMP3 string `json:"audio_mp3,omitempty"`
+	AudioOGG string `json:"audio_ogg,omitempty"`
+	// Path is the local copy of the recording, set once it is downloaded
+	Path string `json:"path,omitempty"`
+}
+
+// Transcription returns the IPA between slashes, the way dictionaries print it
+func (p Pronunciation) Transcription() string {
+	if p.IPA == "" {
+		return ""
+	}
+	return "/" + p.IPA + "/"
+}
+
+// PreferredPronunciation returns the pronunciation of the region, or the first one when the region is missing
+func PreferredPronunciation(pronunciations []Pronunciation, region Region) (Pronunciation, bool) {
+	for _, p := range pronunciations {
+		if p.Region == region {
+			return p, true
+		}
+	}
+	if len(pronunciations) == 0 {
+		return Pronunciation{}, false
+	}
+	return pronunciations[0], true
+}
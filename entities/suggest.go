@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"github.com/marycka9/go-reverso-api/languages"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 const urlContextSuggest = "https://context.reverso.net/bst-suggest-service"
@@ -24,13 +27,36 @@ type SuggestResponse struct {
 	TimeMS      int64             `json:"time_ms"`
 }
 
-func NewSuggestRequest(text string, srcLang, dstLang *languages.Language) *SuggestRequest {
+// SuggestOptions holds the optional query parameters of a suggest request, zero values are left to the service
+type SuggestOptions struct {
+	MaxResults int64
+	Mode       int64
+}
+
+// Suggestion fuzziness levels, from the suggestions list to the second fuzzy list
+const (
+	SuggestionExact = iota
+	SuggestionFuzzy1
+	SuggestionFuzzy2
+)
+
+// RankedSuggestion is a suggestion of the merged view of a SuggestResponse
+type RankedSuggestion struct {
+	Suggestion string
+	Lang       string
+	IsSource   bool // the suggestion is in the source language of the request
+	IsFromDict bool // the suggestion is backed by a dictionary entry
+	Fuzziness  int  // SuggestionExact, SuggestionFuzzy1 or SuggestionFuzzy2
+	Weight     int64
+}
+
+func NewSuggestRequest(text string, srcLang, dstLang *languages.Language, opts SuggestOptions) *SuggestRequest {
 	return &SuggestRequest{
 		Search:     text,
-		SourceLang: srcLang.Code,
-		TargetLang: dstLang.Code,
-		MaxResults: 0,
-		Mode:       0,
+		SourceLang: string(srcLang.Code),
+		TargetLang: string(dstLang.Code),
+		MaxResults: opts.MaxResults,
+		Mode:       opts.Mode,
 	}
 }
 
@@ -46,14 +72,64 @@ func (s *SuggestRequest) GetUrl() string {
 }
 
 func (s *SuggestRequest) GetParams() url.Values {
-	return url.Values{
+	params := url.Values{
 		"search":      []string{s.Search},
 		"source_lang": []string{s.SourceLang},
 		"target_lang": []string{s.TargetLang},
 	}
+	if s.MaxResults > 0 {
+		params.Set("max_results", strconv.FormatInt(s.MaxResults, 10))
+	}
+	if s.Mode > 0 {
+		params.Set("mode", strconv.FormatInt(s.Mode, 10))
+	}
+	return params
 }
 
 func (s *SuggestRequest) MarshalJson() (string, error) {
 	res, err := json.Marshal(&s)
 	return string(res), err
 }
+
+// Ranked merges the suggestion lists into one without duplicates, ordered by fuzziness,
+// then dictionary-backed suggestions first, then by weight
+func (r *SuggestResponse) Ranked() []RankedSuggestion {
+	res := make([]RankedSuggestion, 0, len(r.Suggestions)+len(r.Fuzzy1)+len(r.Fuzzy2))
+	index := make(map[string]int)
+
+	add := func(suggestions []FuzzySuggestion, fuzziness int) {
+		for _, suggestion := range suggestions {
+			key := suggestion.Lang + "\x00" + strings.ToLower(suggestion.Suggestion)
+			if i, ok := index[key]; ok {
+				res[i].IsFromDict = res[i].IsFromDict || suggestion.IsFromDict
+				res[i].Weight = max(res[i].Weight, suggestion.Weight)
+				continue
+			}
+			index[key] = len(res)
+			res = append(res, RankedSuggestion{
+				Suggestion: suggestion.Suggestion,
+				Lang:       suggestion.Lang,
+				IsSource:   suggestion.Lang == r.Request.SourceLang,
+				IsFromDict: suggestion.IsFromDict,
+				Fuzziness:  fuzziness,
+				Weight:     suggestion.Weight,
+			})
+		}
+	}
+
+	add(r.Suggestions, SuggestionExact)
+	add(r.Fuzzy1, SuggestionFuzzy1)
+	add(r.Fuzzy2, SuggestionFuzzy2)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if res[i].Fuzziness != res[j].Fuzziness {
+			return res[i].Fuzziness < res[j].Fuzziness
+		}
+		if res[i].IsFromDict != res[j].IsFromDict {
+			return res[i].IsFromDict
+		}
+		return res[i].Weight > res[j].Weight
+	})
+
+	return res
+}
@@ -1,10 +1,14 @@
 package entities
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/languages"
 	"net/url"
+	"slices"
+	"strconv"
 )
 
 const urlSynonyms = "https://synonyms.reverso.net/api/v2/"
@@ -14,15 +18,52 @@ const endpointSynonymsSearch = "search/"
 const BearerSynonyms = "c3lub255bXM6REtiZTUyRjNZRExZdVFFOHlk" // synonyms:DKbe52F3YDLYuQE8yd  // probably just an identifier
 
 type SynonymRequest struct {
-	Input string `json:"input"`
-	Lang  string `json:"lang"`
+	Input   string         `json:"input"`
+	Lang    string         `json:"lang"`
+	Options SynonymOptions `json:"-"`
 }
 
+// SynonymOptions holds the query parameters of a synonyms search
+type SynonymOptions struct {
+	Limit        int  // results per page
+	Page         int  // 1-based page number, 0 is the first page
+	Rude         bool // include rude entries
+	Colloquial   bool // include colloquial entries
+	Alphabetical bool // order entries alphabetically instead of by relevance
+	Merge        bool // merge close sense clusters
+	// PartOfSpeech restricts the search to a part of speech, given as the Pos.Mask Reverso returns for it, e.g. the
+	// mask of an earlier response's entry. 0 searches every part of speech, see Pos for why masks are not named
+	PartOfSpeech int64
+}
+
+// DefaultSynonymOptions returns the options the Reverso Synonyms site searches with
+func DefaultSynonymOptions() SynonymOptions {
+	return SynonymOptions{
+		Limit:      50,
+		Rude:       true,
+		Colloquial: true,
+		Merge:      true,
+	}
+}
+
+// Pos is the part of speech of a synonyms entry. Desc names it in the language of the search, Mask is a bitmask
+// Reverso does not document. The mask is kept as returned and not decoded: a table of its bits needs responses
+// recorded for every part of speech, which the client/testdata fixtures are not
 type Pos struct {
 	Mask int64    `json:"mask"`
 	Desc []string `json:"desc"`
 }
 
+// PartsOfSpeech returns the normalized parts of speech named by the descriptions, see Pos
+func (p Pos) PartsOfSpeech() []string {
+	return common.GetPartOfSpeechParserInstance().ParsePos(p.Desc)
+}
+
+// HasPartOfSpeech reports whether the descriptions name the normalized part of speech
+func (p Pos) HasPartOfSpeech(partOfSpeech string) bool {
+	return slices.Contains(p.PartsOfSpeech(), partOfSpeech)
+}
+
 type Cluster struct {
 	ID           int64   `json:"id"`
 	Word         string  `json:"word"`
@@ -38,6 +79,34 @@ type Cluster struct {
 	MostRelevant bool    `json:"mostRelevant"`
 }
 
+// Antonym is an opposite of the searched word, Reverso returns it in the shape of a Cluster
+type Antonym Cluster
+
+// Merged holds the clusters Reverso folded into a result when the request is sent with merge=true
+type Merged struct {
+	Pos      Pos       `json:"pos"`
+	Cluster  []Cluster `json:"cluster"`
+	Antonyms []Antonym `json:"antonyms"`
+}
+
+// UnmarshalJSON accepts a missing merge as null or a plain boolean flag instead of an object
+func (m *Merged) UnmarshalJSON(data []byte) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || trimmed[0] != '{' {
+		*m = Merged{}
+		return nil
+	}
+
+	type merged Merged
+	var res merged
+	if err := json.Unmarshal(trimmed, &res); err != nil {
+		return err
+	}
+	*m = Merged(res)
+
+	return nil
+}
+
 type Example struct {
 	ID      int64  `json:"id"`
 	Cluster int64  `json:"cluster"`
@@ -52,17 +121,17 @@ type Stopwatch struct {
 }
 
 type SynonymResult struct {
-	Pos               Pos           `json:"pos"`
-	Weight            int64         `json:"weight"`
-	Nrows             int64         `json:"nrows"`
-	Relevance         int64         `json:"relevance"`
-	RudeResults       int64         `json:"rudeResults"`
-	ColloquialResults int64         `json:"colloquialResults"`
-	Merged            interface{}   `json:"merged"`
-	Relevantitems     int64         `json:"relevantitems"`
-	Cluster           []Cluster     `json:"cluster"`
-	Examples          []Example     `json:"examples"`
-	Antonyms          []interface{} `json:"antonyms"`
+	Pos               Pos       `json:"pos"`
+	Weight            int64     `json:"weight"`
+	Nrows             int64     `json:"nrows"`
+	Relevance         int64     `json:"relevance"`
+	RudeResults       int64     `json:"rudeResults"`
+	ColloquialResults int64     `json:"colloquialResults"`
+	Merged            *Merged   `json:"merged"`
+	Relevantitems     int64     `json:"relevantitems"`
+	Cluster           []Cluster `json:"cluster"`
+	Examples          []Example `json:"examples"`
+	Antonyms          []Antonym `json:"antonyms"`
 }
 
 type Related struct {
@@ -91,25 +160,36 @@ type SynonymsResponse struct {
 	ResultsCount        int64           `json:"resultsCount"`
 	Results             []SynonymResult `json:"results"`
 	Suggestions         []interface{}   `json:"suggestions"`
-	Antonyms            []interface{}   `json:"antonyms"`
+	Antonyms            []Antonym       `json:"antonyms"`
 	Related             []Related       `json:"related"`
 	Stopwatch           Stopwatch       `json:"stopwatch"`
 }
 
-func NewSynonymRequest(text string, language *languages.Language) *SynonymRequest {
+func NewSynonymRequest(text string, language *languages.Language, opts SynonymOptions) *SynonymRequest {
 	return &SynonymRequest{
-		Input: text,
-		Lang:  language.Alpha3,
+		Input:   text,
+		Lang:    language.Alpha3,
+		Options: opts,
 	}
 }
 
 func (s *SynonymRequest) GetParams() url.Values {
-	return url.Values{
-		"limit": []string{"50"},
-		"rude":  []string{"true"},
-		"abc":   []string{"false"},
-		"merge": []string{"true"},
+	params := url.Values{
+		"rude":       []string{strconv.FormatBool(s.Options.Rude)},
+		"colloquial": []string{strconv.FormatBool(s.Options.Colloquial)},
+		"abc":        []string{strconv.FormatBool(s.Options.Alphabetical)},
+		"merge":      []string{strconv.FormatBool(s.Options.Merge)},
+	}
+	if s.Options.Limit > 0 {
+		params.Set("limit", strconv.Itoa(s.Options.Limit))
 	}
+	if s.Options.Page > 1 {
+		params.Set("page", strconv.Itoa(s.Options.Page))
+	}
+	if s.Options.PartOfSpeech != 0 {
+		params.Set("pos", strconv.FormatInt(s.Options.PartOfSpeech, 10))
+	}
+	return params
 }
 
 func (s SynonymRequest) GetUrl(code, text string) string {
@@ -129,3 +209,158 @@ func (s *SynonymRequest) MarshalJson() (string, error) {
 	res, err := json.Marshal(&s)
 	return string(res), err
 }
+
+// ClusterIDs returns the IDs of every synonym of the response, used to detect the end of a paged search
+func (r *SynonymsResponse) ClusterIDs() []int64 {
+	res := make([]int64, 0)
+	for _, result := range r.Results {
+		for _, synonym := range result.synonyms() {
+			res = append(res, synonym.ID)
+		}
+	}
+	return res
+}
+
+// SynonymFilter drops entries Reverso flags as rude or colloquial and, when PartOfSpeech is set, entries of other parts of speech
+type SynonymFilter struct {
+	ExcludeRude       bool
+	ExcludeColloquial bool
+	PartOfSpeech      string
+}
+
+func (f SynonymFilter) allows(pos Pos, rude, colloquial bool) bool {
+	if f.ExcludeRude && rude {
+		return false
+	}
+	if f.ExcludeColloquial && colloquial {
+		return false
+	}
+	if f.PartOfSpeech != "" && !pos.HasPartOfSpeech(f.PartOfSpeech) {
+		return false
+	}
+	return true
+}
+
+// Sense groups the synonyms, antonyms and examples of one sense cluster
+type Sense struct {
+	Cluster  int64
+	Pos      Pos
+	Synonyms []Cluster
+	Antonyms []Antonym
+	Examples []string
+}
+
+// Senses returns the synonyms and antonyms of the response grouped by sense cluster, in the order Reverso ranks them
+func (r *SynonymsResponse) Senses(filter SynonymFilter) []Sense {
+	senses := make([]Sense, 0)
+	index := make(map[int64]int)
+
+	sense := func(cluster int64, pos Pos) *Sense {
+		i, ok := index[cluster]
+		if !ok {
+			i = len(senses)
+			index[cluster] = i
+			senses = append(senses, Sense{Cluster: cluster, Pos: pos})
+		}
+		return &senses[i]
+	}
+
+	for _, result := range r.Results {
+		for _, synonym := range result.synonyms() {
+			if !filter.allows(result.Pos, synonym.Rude, synonym.Colloquial) {
+				continue
+			}
+			s := sense(synonym.Cluster, result.Pos)
+			s.Synonyms = append(s.Synonyms, synonym)
+		}
+		for _, antonym := range result.antonyms() {
+			if !filter.allows(result.Pos, antonym.Rude, antonym.Colloquial) {
+				continue
+			}
+			s := sense(antonym.Cluster, result.Pos)
+			s.Antonyms = append(s.Antonyms, antonym)
+		}
+		for _, example := range result.Examples {
+			if i, ok := index[example.Cluster]; ok {
+				senses[i].Examples = append(senses[i].Examples, example.Example)
+			}
+		}
+	}
+
+	return senses
+}
+
+// SynonymsByPos returns the synonyms of the response grouped by normalized part of speech
+func (r *SynonymsResponse) SynonymsByPos(filter SynonymFilter) map[string][]Cluster {
+	res := make(map[string][]Cluster)
+	for _, result := range r.Results {
+		for _, synonym := range result.synonyms() {
+			if !filter.allows(result.Pos, synonym.Rude, synonym.Colloquial) {
+				continue
+			}
+			for _, pos := range result.Pos.PartsOfSpeech() {
+				res[pos] = append(res[pos], synonym)
+			}
+		}
+	}
+	return res
+}
+
+// AntonymsByPos returns the antonyms of the response grouped by normalized part of speech
+func (r *SynonymsResponse) AntonymsByPos(filter SynonymFilter) map[string][]Antonym {
+	res := make(map[string][]Antonym)
+	for _, result := range r.Results {
+		for _, antonym := range result.antonyms() {
+			if !filter.allows(result.Pos, antonym.Rude, antonym.Colloquial) {
+				continue
+			}
+			for _, pos := range result.Pos.PartsOfSpeech() {
+				res[pos] = append(res[pos], antonym)
+			}
+		}
+	}
+	return res
+}
+
+// AllAntonyms returns every antonym of the response, the top-level ones first, without duplicates
+func (r *SynonymsResponse) AllAntonyms(filter SynonymFilter) []Antonym {
+	res := make([]Antonym, 0)
+	seen := make(map[string]bool)
+
+	add := func(antonyms []Antonym, groupPos Pos) {
+		for _, antonym := range antonyms {
+			pos := groupPos
+			if len(antonym.Pos.Desc) > 0 {
+				pos = antonym.Pos
+			}
+			if seen[antonym.Word] || !filter.allows(pos, antonym.Rude, antonym.Colloquial) {
+				continue
+			}
+			seen[antonym.Word] = true
+			res = append(res, antonym)
+		}
+	}
+
+	add(r.Antonyms, r.Pos)
+	for _, result := range r.Results {
+		add(result.antonyms(), result.Pos)
+	}
+
+	return res
+}
+
+// synonyms returns the clusters of the result, including the ones Reverso merged into it
+func (r SynonymResult) synonyms() []Cluster {
+	if r.Merged == nil {
+		return r.Cluster
+	}
+	return append(append([]Cluster{}, r.Cluster...), r.Merged.Cluster...)
+}
+
+// antonyms returns the antonyms of the result, including the ones Reverso merged into it
+func (r SynonymResult) antonyms() []Antonym {
+	if r.Merged == nil {
+		return r.Antonyms
+	}
+	return append(append([]Antonym{}, r.Antonyms...), r.Merged.Antonyms...)
+}
@@ -2,7 +2,9 @@ package entities
 
 import (
 	"encoding/json"
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/languages"
+	"slices"
 )
 
 type TranslateOptions struct {
@@ -37,6 +39,16 @@ type TranslateResult struct {
 	PartOfSpeech   string   `json:"partOfSpeech"`
 }
 
+// PartsOfSpeech returns the normalized parts of speech of the translation
+func (t TranslateResult) PartsOfSpeech() []string {
+	return common.GetPartOfSpeechParserInstance().ParseAllFor(t.PartOfSpeech, "", common.VocabularyReverso)
+}
+
+// HasPartOfSpeech reports whether the translation is the normalized part of speech
+func (t TranslateResult) HasPartOfSpeech(partOfSpeech string) bool {
+	return slices.Contains(t.PartsOfSpeech(), partOfSpeech)
+}
+
 type ContextResults struct {
 	RudeWords             bool              `json:"rudeWords"`
 	Colloquialisms        bool              `json:"colloquialisms"`
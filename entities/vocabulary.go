@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"slices"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// Enrichment records the fields a source provided for a word and when they were fetched
+type Enrichment struct {
+	Fields    []string  `json:"fields"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// VocabularyEntry is a word of the vocabulary store with its enrichment history, recordings and Anki notes
+type VocabularyEntry struct {
+	Key         string                `json:"key"`
+	Word        Word                  `json:"word"`
+	Enrichments map[string]Enrichment `json:"enrichments,omitempty"` // by source, see Word.Sources
+	Audio       []Pronunciation       `json:"audio,omitempty"`
+	NoteIDs     []int64               `json:"note_ids,omitempty"`
+	AddedAt     time.Time             `json:"added_at"`
+	UpdatedAt   time.Time             `json:"updated_at"`
+}
+
+// VocabularyQuery selects entries of the vocabulary store, the zero value selects them all
+type VocabularyQuery struct {
+	Language     languages.Code
+	PartOfSpeech PartOfSpeech
+	Tag          string
+	// AddedAfter and AddedBefore bound the date the entry was added, AddedAfter included
+	AddedAfter  time.Time
+	AddedBefore time.Time
+}
+
+// Match reports whether the entry is selected by the query
+func (q VocabularyQuery) Match(entry VocabularyEntry) bool {
+	switch {
+	case q.Language != "" && entry.Word.Language != q.Language:
+		return false
+	case q.PartOfSpeech != PosUnknown && entry.Word.Morphology.PartOfSpeech != q.PartOfSpeech:
+		return false
+	case q.Tag != "" && !slices.Contains(entry.Word.Tags, q.Tag):
+		return false
+	case !q.AddedAfter.IsZero() && entry.AddedAt.Before(q.AddedAfter):
+		return false
+	case !q.AddedBefore.IsZero() && !entry.AddedAt.Before(q.AddedBefore):
+		return false
+	}
+	return true
+}
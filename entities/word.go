@@ -1,29 +1,100 @@
 package entities
 
-type Language string
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
 
-const (
-	French  Language = "french"
-	English Language = "english"
-	Russian Language = "russian"
+	"github.com/marycka9/go-reverso-api/common"
+	"github.com/marycka9/go-reverso-api/languages"
 )
 
-type Translations = map[Language][]string
+type Translations = map[languages.Code][]string
 
 type Word struct {
-	Language      Language
-	Term          string
-	TermAlt       string
-	PartOfSpeech  string
-	Transcription string
-	Translations  Translations
-	// TODO:: merge with PartOfSpeech
-	Type string
+	// ID is the key of the word as it was read, kept when enrichment corrects the term. See Key
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Concept links the words of the same meaning across languages, see usecases.WordTranslator
+	Concept       string         `json:"concept,omitempty" yaml:"concept,omitempty"`
+	Language      languages.Code `json:"language" yaml:"language"`
+	Term          string         `json:"term" yaml:"term"`
+	TermAlt       string         `json:"term_alt,omitempty" yaml:"term_alt,omitempty"`
+	Morphology    Morphology     `json:"morphology" yaml:"morphology"`
+	Transcription string         `json:"transcription,omitempty" yaml:"transcription,omitempty"`
+	Translations  Translations   `json:"translations,omitempty" yaml:"translations,omitempty"`
+	// Article and Plural are the forms of nouns, see SetMorphology
+	Article     string                 `json:"article,omitempty" yaml:"article,omitempty"`
+	Plural      string                 `json:"plural,omitempty" yaml:"plural,omitempty"`
+	Conjugation *FrenchVerbConjugation `json:"conjugation,omitempty" yaml:"conjugation,omitempty"`
+	Examples    []ExamplePair          `json:"examples,omitempty" yaml:"examples,omitempty"`
+	// Tags, Deck and Notes come from the vocabulary file and go to the Anki note as they are
+	Tags  []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deck  string   `json:"deck,omitempty" yaml:"deck,omitempty"`
+	Notes string   `json:"notes,omitempty" yaml:"notes,omitempty"`
+	// Sources records which source provided each field, see SetSource
+	Sources map[string]string `json:"sources,omitempty" yaml:"sources,omitempty"`
+}
+
+// Key identifies the word across runs: its ID when set, otherwise language, term and part of speech
+func (w *Word) Key() string {
+	if w.ID != "" {
+		return w.ID
+	}
+	return strings.Join([]string{string(w.Language), strings.ToLower(strings.TrimSpace(w.Term)), string(w.Morphology.PartOfSpeech)}, "|")
+}
+
+// Tag returns an Anki tag derived from Key, Anki tags cannot hold spaces
+func (w *Word) Tag() string {
+	sum := sha1.Sum([]byte(w.Key()))
+	return "word_" + hex.EncodeToString(sum[:8])
+}
+
+// Field names recorded in Word.Sources
+const (
+	FieldTranslations   = "translations"
+	FieldTranscription  = "transcription"
+	FieldAdditionalData = "additional_data"
+)
+
+// SourceFile is the source of the fields given by the vocabulary file, enrichment does not overwrite them
+const SourceFile = "file"
+
+// TranslationsField returns the Word.Sources key of the translations into the language
+func TranslationsField(lang languages.Code) string {
+	return FieldTranslations + "." + string(lang)
+}
+
+// SetMorphology completes the morphology of the word with what a source found and updates the article of nouns
+func (w *Word) SetMorphology(morphology Morphology) {
+	w.Morphology.Merge(morphology)
+	w.Article = ""
+	if w.Morphology.PartOfSpeech == PosNoun {
+		w.Article = common.DefiniteArticle(w.Term, string(w.Morphology.Gender), w.Language)
+	}
+}
+
+// TermWithArticle returns the term prefixed with its article, if it has one
+func (w *Word) TermWithArticle() string {
+	return common.WithArticle(w.Article, w.Term)
+}
+
+// SetSource records the source that provided the field
+func (w *Word) SetSource(field, source string) {
+	if w.Sources == nil {
+		w.Sources = make(map[string]string)
+	}
+	w.Sources[field] = source
+}
+
+// ExamplePair is an example sentence and its translation
+type ExamplePair struct {
+	Source string `json:"source" yaml:"source"`
+	Target string `json:"target,omitempty" yaml:"target,omitempty"`
 }
 
 // Conjugation for verb, before adding new tenses, create a type
 type FrenchVerbConjugation struct {
-	Infinitif string
-	Indicatif map[string][]string
-	Imperatif map[string][]string
+	Infinitif string              `json:"infinitif" yaml:"infinitif"`
+	Indicatif map[string][]string `json:"indicatif,omitempty" yaml:"indicatif,omitempty"`
+	Imperatif map[string][]string `json:"imperatif,omitempty" yaml:"imperatif,omitempty"`
 }
@@ -10,6 +10,8 @@ require (
 	github.com/gocolly/colly v1.2.0
 	github.com/serope/laroussefr v0.0.0-00010101000000-000000000000
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
 
 require (
@@ -44,11 +46,9 @@ require (
 	golang.org/x/crypto v0.31.0 // indirect
 	golang.org/x/net v0.33.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
-	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/appengine v1.6.8 // indirect
 	google.golang.org/protobuf v1.26.0 // indirect
 	gopkg.in/yaml.v2 v2.2.8 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
 )
 
 replace github.com/serope/laroussefr => github.com/Arclight-V/laroussefr v0.0.0-20241222153843-0fa3f577b3b1
@@ -0,0 +1,119 @@
+package languages
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// Service is a dictionary or Reverso service the module sends requests to
+type Service string
+
+// Services of the capability matrix
+const (
+	ServiceTranslate  Service = "translate"
+	ServiceContext    Service = "context"
+	ServiceSynonyms   Service = "synonyms"
+	ServiceSpeak      Service = "speak"
+	ServiceConjugator Service = "conjugator"
+	ServiceCambridge  Service = "cambridge"
+	ServiceLarousse   Service = "larousse"
+)
+
+// ErrUnsupportedLanguage is returned when a service does not handle a language or a language pair
+var ErrUnsupportedLanguage = errors.New("unsupported language")
+
+// capability lists what a service handles. Languages alone allow any pair of distinct languages among them,
+// Hubs restrict those pairs to the ones with a hub on either side, and Pairs lists extra source-target pairs
+type capability struct {
+	Languages []Code    `json:"languages"`
+	Hubs      []Code    `json:"hubs"`
+	Pairs     [][2]Code `json:"pairs"`
+}
+
+//go:embed capabilities.json
+var capabilityData []byte
+
+var (
+	capabilities     map[Service]capability
+	capabilitiesErr  error
+	capabilitiesOnce sync.Once
+)
+
+func loadCapabilities() (map[Service]capability, error) {
+	capabilitiesOnce.Do(func() {
+		if err := json.Unmarshal(capabilityData, &capabilities); err != nil {
+			capabilitiesErr = fmt.Errorf("decode capabilities.json: %w", err)
+		}
+	})
+	return capabilities, capabilitiesErr
+}
+
+func (c capability) language(code Code) bool {
+	if slices.Contains(c.Languages, code) {
+		return true
+	}
+	for _, pair := range c.Pairs {
+		if pair[0] == code || pair[1] == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (c capability) pair(src, dst Code) bool {
+	if slices.Contains(c.Pairs, [2]Code{src, dst}) {
+		return true
+	}
+	if src == dst || !slices.Contains(c.Languages, src) || !slices.Contains(c.Languages, dst) {
+		return false
+	}
+	return len(c.Hubs) == 0 || slices.Contains(c.Hubs, src) || slices.Contains(c.Hubs, dst)
+}
+
+// CheckLanguage returns an error unless the service handles the language
+func CheckLanguage(service Service, lang *Language) error {
+	if lang == nil {
+		return fmt.Errorf("%s: %w: language is nil", service, ErrUnsupportedLanguage)
+	}
+	caps, err := loadCapabilities()
+	if err != nil {
+		return err
+	}
+	if c, ok := caps[service]; ok && c.language(lang.Code) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w: %s", service, ErrUnsupportedLanguage, lang.Name)
+}
+
+// CheckPair returns an error unless the service handles the source-target pair
+func CheckPair(service Service, src, dst *Language) error {
+	if src == nil || dst == nil {
+		return fmt.Errorf("%s: %w: language is nil", service, ErrUnsupportedLanguage)
+	}
+	caps, err := loadCapabilities()
+	if err != nil {
+		return err
+	}
+	if c, ok := caps[service]; ok && c.pair(src.Code, dst.Code) {
+		return nil
+	}
+	return fmt.Errorf("%s: %w: %s-%s", service, ErrUnsupportedLanguage, src.Name, dst.Name)
+}
+
+// Pairs returns every source-target pair the service handles
+func Pairs(service Service) [][2]*Language {
+	caps, _ := loadCapabilities()
+	res := make([][2]*Language, 0)
+	for _, src := range All() {
+		for _, dst := range All() {
+			if c, ok := caps[service]; ok && c.pair(src.Code, dst.Code) {
+				res = append(res, [2]*Language{src, dst})
+			}
+		}
+	}
+	return res
+}
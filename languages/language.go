@@ -3,10 +3,47 @@ package languages
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 )
 
+// Code is the language code Reverso uses: the ISO 639-1 one, except ua for Ukrainian (uk in ISO 639-1)
+type Code string
+
+// Languages known to the registry
+const (
+	Arabic     Code = "ar"
+	German     Code = "de"
+	English    Code = "en"
+	Spanish    Code = "es"
+	French     Code = "fr"
+	Portuguese Code = "pt"
+	Italian    Code = "it"
+	Dutch      Code = "nl"
+	Russian    Code = "ru"
+	Hebrew     Code = "he"
+	Polish     Code = "pl"
+	Romanian   Code = "ro"
+	Japanese   Code = "ja"
+	Turkish    Code = "tr"
+	Chinese    Code = "zh"
+	Ukrainian  Code = "ua"
+)
+
+// isoCodes maps the ISO 639-1 codes Reverso spells differently to its codes
+var isoCodes = map[string]Code{
+	"uk": Ukrainian,
+}
+
+// ErrUnknownLanguage is returned when a lookup matches no language of the registry
+var ErrUnknownLanguage = errors.New("unknown language")
+
 type Language struct {
-	Code   string `json:"code"`
+	Name   string `json:"-"`
+	Code   Code   `json:"code"`
 	Alpha3 string `json:"alpha3"`
 }
 
@@ -15,8 +52,91 @@ var langData []byte
 
 type Languages map[string]*Language
 
+var (
+	registry     map[Code]*Language
+	registryErr  error
+	registryOnce sync.Once
+)
+
+// load decodes the embedded languages once
+func load() (map[Code]*Language, error) {
+	registryOnce.Do(func() {
+		byName := make(map[string]*Language)
+		if err := json.Unmarshal(langData, &byName); err != nil {
+			registryErr = fmt.Errorf("decode languages.json: %w", err)
+			return
+		}
+		registry = make(map[Code]*Language, len(byName))
+		for name, lang := range byName {
+			lang.Name = name
+			registry[lang.Code] = lang
+		}
+	})
+	return registry, registryErr
+}
+
+// Get returns the language of the code
+func Get(code Code) (*Language, error) {
+	langs, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if lang, ok := langs[code]; ok {
+		return lang, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, code)
+}
+
+// MustGet is like Get but panics on an unknown code. Use it with the constants of this package only
+func MustGet(code Code) *Language {
+	lang, err := Get(code)
+	if err != nil {
+		panic(err)
+	}
+	return lang
+}
+
+// Lookup returns the language matching the English name, the code, the ISO 639-1 code or the alpha3 code,
+// case-insensitively
+func Lookup(s string) (*Language, error) {
+	langs, err := load()
+	if err != nil {
+		return nil, err
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	if code, ok := isoCodes[s]; ok {
+		s = string(code)
+	}
+	for _, lang := range langs {
+		if lang.Name == s || string(lang.Code) == s || lang.Alpha3 == s {
+			return lang, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownLanguage, s)
+}
+
+// All returns a copy of every language of the registry ordered by name
+func All() []*Language {
+	langs, _ := load()
+	res := make([]*Language, 0, len(langs))
+	for _, lang := range langs {
+		copied := *lang
+		res = append(res, &copied)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// GetLanguages returns copies of the languages keyed by English name, changing them leaves the registry as it is.
+// Prefer Get and Lookup, which report unknown languages
 func GetLanguages() Languages {
-	result := make(map[string]*Language, 0)
-	_ = json.Unmarshal(langData, &result)
+	langs, _ := load()
+	result := make(Languages, len(langs))
+	for _, lang := range langs {
+		copied := *lang
+		result[lang.Name] = &copied
+	}
 	return result
 }
@@ -0,0 +1,15 @@
+package languages
+
+import "testing"
+
+func TestGetLanguagesReturnsCopies(t *testing.T) {
+	GetLanguages()["french"].Alpha3 = "xxx"
+	All()[0].Code = "xx"
+
+	if lang := MustGet(French); lang.Alpha3 != "fra" {
+		t.Errorf("registry alpha3 of French = %q, changed through GetLanguages", lang.Alpha3)
+	}
+	if _, err := Lookup("xx"); err == nil {
+		t.Error("registry code changed through All")
+	}
+}
@@ -1,19 +1,26 @@
 package main
 
 import (
+	"log"
+
 	"github.com/marycka9/go-reverso-api/client"
 	"github.com/marycka9/go-reverso-api/languages"
 )
 
 func main() {
 	client := client.NewClient()
-	langs := languages.GetLanguages()
-	engl := langs["englis"]
-	res, err := client.Translate("Hello", langs["english"], langs["russian"])
-	res1, err := client.Synonyms("Hello", langs["english"])
-	res2, err := client.AutoComplete("Hello", langs["english"])
-	res3, err := client.Context("sky", langs["english"], langs["french"], 1)
-	res4, err := client.Suggest("sky", langs["english"], langs["french"])
+	english, err := languages.Lookup("english")
+	if err != nil {
+		log.Fatal(err)
+	}
+	russian := languages.MustGet(languages.Russian)
+	french := languages.MustGet(languages.French)
+
+	res, err := client.Translate("Hello", english, russian)
+	res1, err := client.Synonyms("Hello", english)
+	res2, err := client.AutoComplete("Hello", english)
+	res3, err := client.Context("sky", english, french, 1)
+	res4, err := client.Suggest("sky", english, french)
 	err = client.Speak("example123", "data/user1", "sky", 128, 100)
 
 	_ = res
@@ -0,0 +1,132 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/atselvan/ankiconnect"
+)
+
+// AnkiResult is what AnkiRepository.Save did with a note
+type AnkiResult string
+
+const (
+	AnkiAdded     AnkiResult = "added"
+	AnkiUpdated   AnkiResult = "updated"
+	AnkiUnchanged AnkiResult = "unchanged"
+)
+
+// AnkiReport counts the notes saved by AnkiRepository, by result
+type AnkiReport struct {
+	Added     int
+	Updated   int
+	Unchanged int
+}
+
+func (r AnkiReport) String() string {
+	return fmt.Sprintf("Anki notes: %d added, %d updated, %d unchanged", r.Added, r.Updated, r.Unchanged)
+}
+
+// AnkiRepository saves notes to Anki without duplicating them across runs: a note is identified by a key tag,
+// such as entities.Word.Tag, with its deck and note type
+type AnkiRepository struct {
+	client *ankiconnect.Client
+	mu     sync.Mutex
+	report AnkiReport
+}
+
+func NewAnkiRepository(client *ankiconnect.Client) *AnkiRepository {
+	return &AnkiRepository{
+		client: client,
+	}
+}
+
+// ankiSearch returns a term of an Anki search, quoted and with the wildcards of the value escaped
+func ankiSearch(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `*`, `\*`, `_`, `\_`).Replace(value)
+	return fmt.Sprintf(`"%s:%s"`, name, value)
+}
+
+// Save adds the note unless notes of its deck and note type already have the key tag. The fields of the ones
+// that differ from the note are updated, their tags are left as they are. It returns the IDs of the notes
+// added or found
+func (r *AnkiRepository) Save(note ankiconnect.Note, key string) (AnkiResult, []int64, error) {
+	if key == "" {
+		return "", nil, errors.New("anki note key cannot be empty")
+	}
+	query := strings.Join([]string{ankiSearch("tag", key), ankiSearch("deck", note.DeckName), ankiSearch("note", note.ModelName)}, " ")
+	existing, restErr := r.client.Notes.Get(query)
+	if restErr != nil {
+		return "", nil, fmt.Errorf("search notes of %s: %s", note.DeckName, restErr.Message)
+	}
+
+	var notes []ankiconnect.ResultNotesInfo
+	if existing != nil {
+		notes = *existing
+	}
+
+	result := AnkiUnchanged
+	ids := make([]int64, 0, len(notes))
+	if len(notes) == 0 {
+		result = AnkiAdded
+		if restErr := r.client.Notes.Add(note); restErr != nil {
+			return "", nil, fmt.Errorf("add note to %s: %s", note.DeckName, restErr.Message)
+		}
+		// AnkiConnect returns the ID of the note added, the client does not, so it is searched for
+		added, restErr := r.client.Notes.Search(query)
+		if restErr != nil {
+			return "", nil, fmt.Errorf("search note added to %s: %s", note.DeckName, restErr.Message)
+		}
+		if added != nil {
+			ids = append(ids, *added...)
+		}
+	}
+	for _, info := range notes {
+		ids = append(ids, info.NoteId)
+		fields := make(ankiconnect.Fields, len(info.Fields))
+		for name, field := range info.Fields {
+			fields[name] = field.Value
+		}
+		if maps.Equal(fields, mergeFields(fields, note.Fields)) {
+			continue
+		}
+		result = AnkiUpdated
+		if restErr := r.client.Notes.Update(ankiconnect.UpdateNote{Id: info.NoteId, Fields: note.Fields}); restErr != nil {
+			return "", nil, fmt.Errorf("update note %d of %s: %s", info.NoteId, note.DeckName, restErr.Message)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch result {
+	case AnkiAdded:
+		r.report.Added++
+	case AnkiUpdated:
+		r.report.Updated++
+	default:
+		r.report.Unchanged++
+	}
+	return result, ids, nil
+}
+
+// mergeFields returns the fields of the note once updated. The fields the update does not name are kept, and those
+// the note type does not have are ignored, as Anki does
+func mergeFields(fields, update ankiconnect.Fields) ankiconnect.Fields {
+	merged := maps.Clone(fields)
+	for name, value := range update {
+		if _, ok := merged[name]; ok {
+			merged[name] = value
+		}
+	}
+	return merged
+}
+
+// Report returns the counts of the notes saved so far
+func (r *AnkiRepository) Report() AnkiReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.report
+}
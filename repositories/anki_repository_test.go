@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/atselvan/ankiconnect"
+	"github.com/marycka9/go-reverso-api/ankistub"
+)
+
+func TestAnkiRepositorySave(t *testing.T) {
+	collection := ankistub.NewCollection()
+	server := httptest.NewServer(collection)
+	defer server.Close()
+
+	note := func(back string) ankiconnect.Note {
+		return ankiconnect.Note{
+			DeckName:  "Francais_mots",
+			ModelName: "Basic (and reversed card french)",
+			Fields:    ankiconnect.Fields{"Front": "le chien", "Back": back},
+			Tags:      []string{"word_1"},
+		}
+	}
+	runs := []struct {
+		name   string
+		note   ankiconnect.Note
+		result AnkiResult
+	}{
+		{name: "first run", note: note("собака"), result: AnkiAdded},
+		{name: "rerun", note: note("собака"), result: AnkiUnchanged},
+		{name: "changed back", note: note("собака<br>пёс"), result: AnkiUpdated},
+		{name: "rerun after the update", note: note("собака<br>пёс"), result: AnkiUnchanged},
+	}
+	var ids []int64
+	for _, run := range runs {
+		// Every run is a new importer, only the collection is kept
+		repo := NewAnkiRepository(ankiconnect.NewClient().SetURL(server.URL))
+		result, saved, err := repo.Save(run.note, "word_1")
+		if err != nil {
+			t.Fatalf("%s: %s", run.name, err)
+		}
+		if result != run.result {
+			t.Errorf("%s: result = %s, want %s", run.name, result, run.result)
+		}
+		if ids == nil {
+			ids = saved
+		}
+		if len(saved) != 1 || saved[0] != ids[0] {
+			t.Errorf("%s: note IDs = %v, want %v", run.name, saved, ids)
+		}
+		if n := collection.Len(); n != 1 {
+			t.Errorf("%s: %d notes in the collection, want 1", run.name, n)
+		}
+	}
+}
+
+func TestAnkiRepositorySaveUnknownField(t *testing.T) {
+	collection := ankistub.NewCollection()
+	collection.AddModel("Basic (and reversed card french)", "Front", "Back")
+	server := httptest.NewServer(collection)
+	defer server.Close()
+
+	// The note type has no Example field, Anki drops it so the note is unchanged on every rerun
+	note := ankiconnect.Note{
+		DeckName:  "Francais_mots",
+		ModelName: "Basic (and reversed card french)",
+		Fields:    ankiconnect.Fields{"Front": "le chien", "Back": "собака", "Example": "le chien aboie"},
+		Tags:      []string{"word_1"},
+	}
+	for _, want := range []AnkiResult{AnkiAdded, AnkiUnchanged, AnkiUnchanged} {
+		result, _, err := NewAnkiRepository(ankiconnect.NewClient().SetURL(server.URL)).Save(note, "word_1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != want {
+			t.Errorf("result = %s, want %s", result, want)
+		}
+	}
+}
@@ -1,45 +1,610 @@
 package repositories
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
 )
 
-type CSVRepository struct{}
+// Encodings of CSVOptions.Encoding
+const (
+	EncodingAuto    = ""
+	EncodingUTF8    = "utf-8"
+	EncodingUTF16   = "utf-16"
+	EncodingWindows = "windows-1252"
+)
+
+// Columns of a vocabulary file with a header. Files without one hold the term and the part of speech only
+const (
+	ColumnTerm    = "term"
+	ColumnPos     = "pos"
+	ColumnAlt     = "alt"
+	ColumnGender  = "gender"
+	ColumnTags    = "tags"
+	ColumnDeck    = "deck"
+	ColumnNotes   = "notes"
+	ColumnExample = "example"
+	// ColumnExamples holds several examples, written by Save, see formatExamples
+	ColumnExamples = "examples"
+	// ColumnID, ColumnLanguage, ColumnTranscription and ColumnPlural are written by Save with the enrichment results
+	ColumnID            = "id"
+	ColumnLanguage      = "language"
+	ColumnTranscription = "transcription"
+	ColumnPlural        = "plural"
+	// ColumnConcept links the rows of the same meaning across files, see usecases.WordTranslator
+	ColumnConcept = "concept"
+	// ColumnTranslation prefixes the columns overriding the translations into a language, e.g. translation_ru
+	ColumnTranslation = "translation_"
+)
+
+// columnAliases maps the header names accepted for each column
+var columnAliases = map[string]string{
+	"term":           ColumnTerm,
+	"word":           ColumnTerm,
+	"mot":            ColumnTerm,
+	"pos":            ColumnPos,
+	"part_of_speech": ColumnPos,
+	"type":           ColumnPos,
+	"alt":            ColumnAlt,
+	"alt_form":       ColumnAlt,
+	"term_alt":       ColumnAlt,
+	"gender":         ColumnGender,
+	"tags":           ColumnTags,
+	"deck":           ColumnDeck,
+	"notes":          ColumnNotes,
+	"example":        ColumnExample,
+	"examples":       ColumnExamples,
+	"id":             ColumnID,
+	"language":       ColumnLanguage,
+	"lang":           ColumnLanguage,
+	"transcription":  ColumnTranscription,
+	"ipa":            ColumnTranscription,
+	"plural":         ColumnPlural,
+	"concept":        ColumnConcept,
+	"concept_id":     ColumnConcept,
+	"link":           ColumnConcept,
+}
+
+// exampleSeparator separates an example from its translation, e.g. "le chat dort => the cat sleeps". The example
+// column holds one example, cut on the first separator; the examples column is a list, see formatExamples
+const exampleSeparator = " => "
+
+// CSVOptions configures how vocabulary files are read
+type CSVOptions struct {
+	// Delimiter separates the fields, 0 guesses it from the first line among ';', ',' and tab
+	Delimiter rune
+	// Encoding is one of the Encoding constants, EncodingAuto detects UTF-16 from the byte order mark
+	// and falls back to Windows-1252 when the file is not valid UTF-8
+	Encoding string
+}
+
+// DefaultCSVOptions returns the options guessing both the delimiter and the encoding
+func DefaultCSVOptions() CSVOptions {
+	return CSVOptions{}
+}
+
+// CSVIssue is a problem found on a line of a vocabulary file
+type CSVIssue struct {
+	Line    int
+	Column  string
+	Message string
+	// Skipped is set when the row was left out of the import
+	Skipped bool
+}
+
+func (i CSVIssue) String() string {
+	column := ""
+	if i.Column != "" {
+		column = fmt.Sprintf(" (%s)", i.Column)
+	}
+	action := "warning"
+	if i.Skipped {
+		action = "skipped"
+	}
+	return fmt.Sprintf("line %d%s: %s, %s", i.Line, column, i.Message, action)
+}
+
+// CSVReport is the validation report of a vocabulary file
+type CSVReport struct {
+	Path     string
+	Rows     int
+	Imported int
+	Issues   []CSVIssue
+}
+
+func (r *CSVReport) add(line int, column, message string, skipped bool) {
+	r.Issues = append(r.Issues, CSVIssue{Line: line, Column: column, Message: message, Skipped: skipped})
+}
+
+// Skipped returns the number of rows left out of the import
+func (r *CSVReport) Skipped() int {
+	return r.Rows - r.Imported
+}
+
+// String returns a summary followed by one line per issue
+func (r *CSVReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s: %d rows, %d imported, %d skipped", r.Path, r.Rows, r.Imported, r.Skipped())
+	for _, issue := range r.Issues {
+		builder.WriteString("\n ")
+		builder.WriteString(issue.String())
+	}
+	return builder.String()
+}
+
+type CSVRepository struct {
+	options CSVOptions
+}
 
 func NewCSVRepository() *CSVRepository {
-	return &CSVRepository{}
+	return NewCSVRepositoryWithOptions(DefaultCSVOptions())
+}
+
+func NewCSVRepositoryWithOptions(options CSVOptions) *CSVRepository {
+	return &CSVRepository{
+		options: options,
+	}
+}
+
+// ReadWordsFromFile reads every valid row of the file, see EachWord for the report of the other ones
+func (r *CSVRepository) ReadWordsFromFile(filePath string, language languages.Code) ([]entities.Word, *CSVReport, error) {
+	var words []entities.Word
+	report, err := r.EachWord(filePath, language, func(word entities.Word) error {
+		words = append(words, word)
+		return nil
+	})
+	return words, report, err
 }
 
-func (r *CSVRepository) ReadWordsFromFile(filePath string, language entities.Language) ([]entities.Word, error) {
+// EachWord streams the words of the file to fn, one row at a time. Invalid rows are reported and skipped
+// rather than failing the import; the error is only set when the file cannot be read or fn fails
+func (r *CSVRepository) EachWord(filePath string, language languages.Code, fn func(word entities.Word) error) (*CSVReport, error) {
+	report := &CSVReport{Path: filePath}
+
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return report, err
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.Comma = ';'
-	records, err := reader.ReadAll()
+	reader, err := newCSVReader(file, r.options)
+	if err != nil {
+		return report, err
+	}
+
+	var columns []string
+	var legacy bool
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return report, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			report.add(parseErr.StartLine, "", parseErr.Err.Error(), true)
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if columns == nil {
+			var header bool
+			if columns, header = parseHeader(record, line, report); header {
+				continue
+			}
+			legacy = true
+		}
+
+		report.Rows++
+		word, ok := parseRow(record, columns, legacy, language, line, report)
+		if !ok {
+			continue
+		}
+		if err := fn(word); err != nil {
+			return report, err
+		}
+		report.Imported++
+	}
+}
+
+// newCSVReader returns a reader of the records of the file, decoded and split as the options say
+func newCSVReader(file io.Reader, options CSVOptions) (*csv.Reader, error) {
+	decoded, err := decode(bufio.NewReader(file), options.Encoding)
 	if err != nil {
 		return nil, err
 	}
+	input := bufio.NewReader(decoded)
 
-	var words []entities.Word
-	for _, record := range records {
-		if len(record) < 2 {
-			return nil, errors.New("invalid CSV format")
+	delimiter := options.Delimiter
+	if delimiter == 0 {
+		if delimiter, err = sniffDelimiter(input); err != nil {
+			return nil, err
 		}
-		words = append(words, entities.Word{
-			Language:     language,
-			Term:         strings.TrimSpace(record[0]),
-			PartOfSpeech: strings.TrimSpace(record[1]),
-		})
 	}
 
+	reader := csv.NewReader(input)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	// Trimming a tab or a space delimiter would merge the empty fields with the next ones
+	reader.TrimLeadingSpace = delimiter != '\t' && delimiter != ' '
+	return reader, nil
+}
+
+// decode returns the input as UTF-8, without the byte order mark
+func decode(input *bufio.Reader, name string) (io.Reader, error) {
+	var enc encoding.Encoding
+	switch name {
+	case EncodingUTF8:
+		enc = unicode.UTF8BOM
+	case EncodingUTF16:
+		enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case EncodingWindows:
+		enc = charmap.Windows1252
+	case EncodingAuto:
+		head, err := input.Peek(4096)
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+			return nil, err
+		}
+		switch {
+		case bytes.HasPrefix(head, []byte{0xFF, 0xFE}), bytes.HasPrefix(head, []byte{0xFE, 0xFF}):
+			enc = unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+		case validUTF8Prefix(head):
+			enc = unicode.UTF8BOM
+		default:
+			enc = charmap.Windows1252
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", name)
+	}
+	return transform.NewReader(input, enc.NewDecoder()), nil
+}
+
+// validUTF8Prefix reports whether the buffer is valid UTF-8, a rune cut at its end included
+func validUTF8Prefix(head []byte) bool {
+	for len(head) > 0 {
+		r, size := utf8.DecodeRune(head)
+		if r == utf8.RuneError && size == 1 {
+			return len(head) < utf8.UTFMax && !utf8.FullRune(head)
+		}
+		head = head[size:]
+	}
+	return true
+}
+
+// sniffDelimiter guesses the delimiter from the first line, ';' winning ties as the historical one
+func sniffDelimiter(input *bufio.Reader) (rune, error) {
+	head, err := input.Peek(input.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return 0, err
+	}
+	firstLine, _, _ := bytes.Cut(head, []byte{'\n'})
+
+	delimiter, count := ';', bytes.Count(firstLine, []byte{';'})
+	for _, candidate := range []rune{',', '\t'} {
+		if n := bytes.Count(firstLine, []byte(string(candidate))); n > count {
+			delimiter, count = candidate, n
+		}
+	}
+	return delimiter, nil
+}
+
+// parseHeader returns the columns of the file: the header when the first row is one, term;pos otherwise. A header
+// names the term column and either names a column in every non-empty cell or in two of them at least, so that a
+// legacy row such as "word;n" is not taken for one
+func parseHeader(record []string, line int, report *CSVReport) ([]string, bool) {
+	columns := make([]string, len(record))
+	unknown := make([]string, 0)
+	term := false
+	known := 0
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if column, ok := columnAliases[name]; ok {
+			columns[i] = column
+			term = term || column == ColumnTerm
+			known++
+			continue
+		}
+		if code, ok := strings.CutPrefix(strings.ReplaceAll(name, ".", "_"), ColumnTranslation); ok {
+			if lang, err := languages.Lookup(code); err == nil {
+				columns[i] = ColumnTranslation + string(lang.Code)
+				known++
+				continue
+			}
+		}
+		// Unknown columns are kept empty so their values are ignored
+		unknown = append(unknown, name)
+	}
+	if !term || len(unknown) > 0 && known < 2 {
+		return []string{ColumnTerm, ColumnPos}, false
+	}
+
+	for _, name := range unknown {
+		report.add(line, name, "unknown column ignored", false)
+	}
+	return columns, true
+}
+
+// splitList splits the values of a list column, such as tags "food animals" or translations "собака|пёс"
+func splitList(value string, separators string) []string {
+	res := make([]string, 0)
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return strings.ContainsRune(separators, r) }) {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	return res
+}
+
+// parseRow builds the word of a row, reporting its problems. ok is false when the row must be skipped
+// Rows of files without a header must have the part of speech, as the first version of the format required.
+// The language column, when there is one, overrides the language of the file
+func parseRow(record, columns []string, legacy bool, language languages.Code, line int, report *CSVReport) (entities.Word, bool) {
+	word := entities.Word{Language: language}
+	if len(record) > len(columns) {
+		report.add(line, "", fmt.Sprintf("%d fields for %d columns, the extra ones are ignored", len(record), len(columns)), false)
+	}
+
+	var pos, gender string
+	for i, value := range record {
+		if i >= len(columns) {
+			break
+		}
+		value = strings.TrimSpace(value)
+		switch column := columns[i]; column {
+		case ColumnTerm:
+			word.Term = value
+		case ColumnPos:
+			pos = value
+		case ColumnAlt:
+			word.TermAlt = value
+		case ColumnGender:
+			gender = value
+		case ColumnTags:
+			word.Tags = splitList(value, " ,")
+		case ColumnDeck:
+			word.Deck = value
+		case ColumnNotes:
+			word.Notes = value
+		case ColumnExample:
+			if value != "" {
+				source, target, _ := strings.Cut(value, exampleSeparator)
+				word.Examples = append(word.Examples, entities.ExamplePair{Source: strings.TrimSpace(source), Target: strings.TrimSpace(target)})
+			}
+		case ColumnExamples:
+			word.Examples = append(word.Examples, parseExamples(value)...)
+		case ColumnID:
+			word.ID = value
+		case ColumnConcept:
+			word.Concept = value
+		case ColumnLanguage:
+			if value == "" {
+				continue
+			}
+			lang, err := languages.Lookup(value)
+			if err != nil {
+				report.add(line, ColumnLanguage, fmt.Sprintf("unknown language %q", value), true)
+				return word, false
+			}
+			word.Language = lang.Code
+		case ColumnTranscription:
+			word.Transcription = value
+			if value != "" {
+				word.SetSource(entities.FieldTranscription, entities.SourceFile)
+			}
+		case ColumnPlural:
+			word.Plural = value
+		default:
+			code, ok := strings.CutPrefix(column, ColumnTranslation)
+			if !ok || value == "" {
+				continue
+			}
+			if word.Translations == nil {
+				word.Translations = make(entities.Translations)
+			}
+			lang := languages.Code(code)
+			word.Translations[lang] = splitList(value, "|")
+			word.SetSource(entities.TranslationsField(lang), entities.SourceFile)
+		}
+	}
+
+	if word.Term == "" {
+		report.add(line, ColumnTerm, "empty term", true)
+		return word, false
+	}
+	if legacy && len(record) < 2 {
+		report.add(line, ColumnPos, "missing part of speech", true)
+		return word, false
+	}
+	if word.Language == "" {
+		report.add(line, ColumnLanguage, "missing language", true)
+		return word, false
+	}
+
+	word.Morphology = entities.ParseMorphologyFor(word.Language, "", pos)
+	if pos != "" && !word.Morphology.Known() {
+		report.add(line, ColumnPos, fmt.Sprintf("unknown part of speech %q", pos), false)
+	}
+	if gender != "" {
+		parsed := common.ParseGender(gender)
+		if parsed == "" {
+			report.add(line, ColumnGender, fmt.Sprintf("unknown gender %q", gender), false)
+		}
+		word.SetMorphology(entities.Morphology{Gender: entities.Gender(parsed)})
+	}
+	return word, true
+}
+
+// Load reads a file written by Save, or any vocabulary file with a language column. Unlike EachWord it fails
+// when rows are skipped, as a reviewed file is expected to be complete
+func (r *CSVRepository) Load(filePath string) ([]entities.Word, error) {
+	words, report, err := r.ReadWordsFromFile(filePath, "")
+	if err != nil {
+		return nil, err
+	}
+	if report.Skipped() > 0 {
+		return nil, errors.New(report.String())
+	}
 	return words, nil
 }
+
+// Save writes the words with a header, in the columns ReadWordsFromFile reads back. The conjugations and the
+// sources of the fields are left out, see JSONLinesRepository for a lossless format
+func (r *CSVRepository) Save(filePath string, words []entities.Word) error {
+	codes := make([]string, 0)
+	for _, word := range words {
+		for code := range word.Translations {
+			if !slices.Contains(codes, string(code)) {
+				codes = append(codes, string(code))
+			}
+		}
+	}
+	slices.Sort(codes)
+
+	header := []string{ColumnID, ColumnConcept, ColumnLanguage, ColumnTerm, ColumnAlt, ColumnPos, ColumnGender, ColumnPlural,
+		ColumnTranscription, ColumnTags, ColumnDeck, ColumnNotes, ColumnExamples}
+	for _, code := range codes {
+		header = append(header, ColumnTranslation+code)
+	}
+
+	delimiter := r.options.Delimiter
+	if delimiter == 0 {
+		delimiter = ';'
+	}
+	return writeFile(filePath, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+		writer.Comma = delimiter
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		for _, word := range words {
+			if err := writer.Write(formatRow(word, codes)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// SaveLegacy writes the words in the layout of the hand-written vocabulary files, a term and its part of speech
+// per row without header, e.g. "chien;noun masculine". Words with a concept are written under a term;pos;concept
+// header with their concept, so that the files link back the same words. Everything else about the words is left out
+func (r *CSVRepository) SaveLegacy(filePath string, words []entities.Word) error {
+	delimiter := r.options.Delimiter
+	if delimiter == 0 {
+		delimiter = ';'
+	}
+	concepts := slices.ContainsFunc(words, func(word entities.Word) bool { return word.Concept != "" })
+	return writeFile(filePath, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+		writer.Comma = delimiter
+		if concepts {
+			if err := writer.Write([]string{ColumnTerm, ColumnPos, ColumnConcept}); err != nil {
+				return err
+			}
+		}
+		for _, word := range words {
+			record := []string{word.Term, word.Morphology.String()}
+			if concepts {
+				record = append(record, word.Concept)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// formatRow returns the fields of the word in the columns of Save. The gender has its own column so the part of
+// speech column reads as the file would have been written by hand, e.g. "verb transitive"
+func formatRow(word entities.Word, codes []string) []string {
+	morphology := word.Morphology
+	morphology.Gender = entities.GenderNone
+
+	row := []string{word.ID, word.Concept, string(word.Language), word.Term, word.TermAlt, morphology.String(),
+		string(word.Morphology.Gender), word.Plural, word.Transcription, strings.Join(word.Tags, " "), word.Deck,
+		word.Notes, formatExamples(word.Examples)}
+	for _, code := range codes {
+		row = append(row, strings.Join(word.Translations[languages.Code(code)], "|"))
+	}
+	return row
+}
+
+// exampleEscaper escapes the characters of the examples column: '|' separates the examples and '=' would start
+// an exampleSeparator, e.g. "a => b" is written "a \=> b"
+var exampleEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, `=`, `\=`)
+
+// formatExamples returns the value of the examples column: the examples separated by '|', each one followed by
+// exampleSeparator and its translation when it has one, the separators of their text escaped by a backslash
+func formatExamples(examples []entities.ExamplePair) string {
+	values := make([]string, 0, len(examples))
+	for _, example := range examples {
+		if example.Target == "" {
+			values = append(values, exampleEscaper.Replace(example.Source))
+			continue
+		}
+		values = append(values, exampleEscaper.Replace(example.Source)+exampleSeparator+exampleEscaper.Replace(example.Target))
+	}
+	return strings.Join(values, "|")
+}
+
+// parseExamples reads the value of the examples column written by formatExamples
+func parseExamples(value string) []entities.ExamplePair {
+	res := make([]entities.ExamplePair, 0)
+	var source, current strings.Builder
+	translated := false
+	add := func() {
+		example := entities.ExamplePair{Source: strings.TrimSpace(current.String())}
+		if translated {
+			example = entities.ExamplePair{Source: strings.TrimSpace(source.String()), Target: example.Source}
+		}
+		if example.Source != "" || example.Target != "" {
+			res = append(res, example)
+		}
+		source.Reset()
+		current.Reset()
+		translated = false
+	}
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value):
+			i++
+			current.WriteByte(value[i])
+		case value[i] == '|':
+			add()
+		case !translated && strings.HasPrefix(value[i:], exampleSeparator):
+			source.WriteString(current.String())
+			current.Reset()
+			translated = true
+			i += len(exampleSeparator) - 1
+		default:
+			current.WriteByte(value[i])
+		}
+	}
+	add()
+	return res
+}
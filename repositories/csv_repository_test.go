@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// utf16LE encodes the text as UTF-16 little endian with a byte order mark
+func utf16LE(text string) []byte {
+	res := []byte{0xFF, 0xFE}
+	for _, r := range text {
+		res = append(res, byte(r), byte(r>>8))
+	}
+	return res
+}
+
+func TestCSVRepositoryReadWordsFromFile(t *testing.T) {
+	type row struct {
+		term string
+		pos  entities.PartOfSpeech
+		alt  string
+	}
+	tests := []struct {
+		name     string
+		content  []byte
+		options  CSVOptions
+		language languages.Code
+		words    []row
+		issues   []CSVIssue
+	}{
+		{
+			name:     "legacy rows",
+			content:  []byte("sous;préposition\nchien; n\nblond; adj\n"),
+			language: languages.French,
+			words:    []row{{term: "sous", pos: entities.PosPreposition}, {term: "chien", pos: entities.PosNoun}, {term: "blond", pos: entities.PosAdjective}},
+		},
+		{
+			// The first term is a column name, the row is a word all the same
+			name:     "legacy row starting with a column name",
+			content:  []byte("word;n\nchien;n\n"),
+			language: languages.English,
+			words:    []row{{term: "word", pos: entities.PosNoun}, {term: "chien", pos: entities.PosNoun}},
+		},
+		{
+			name:     "header with an unknown column",
+			content:  []byte("term;pos;source\nchien;n;book\n"),
+			language: languages.French,
+			words:    []row{{term: "chien", pos: entities.PosNoun}},
+			issues:   []CSVIssue{{Line: 1, Column: "source", Message: "unknown column ignored"}},
+		},
+		{
+			name:     "legacy row without part of speech",
+			content:  []byte("chien; n\nchat\n"),
+			language: languages.French,
+			words:    []row{{term: "chien", pos: entities.PosNoun}},
+			issues:   []CSVIssue{{Line: 2, Column: ColumnPos, Message: "missing part of speech", Skipped: true}},
+		},
+		{
+			name:     "utf-8 byte order mark",
+			content:  append([]byte{0xEF, 0xBB, 0xBF}, "term;pos\nélève;n\n"...),
+			language: languages.French,
+			words:    []row{{term: "élève", pos: entities.PosNoun}},
+		},
+		{
+			name:     "utf-16",
+			content:  utf16LE("term;pos\r\nélève;n\r\n"),
+			language: languages.French,
+			words:    []row{{term: "élève", pos: entities.PosNoun}},
+		},
+		{
+			name:     "windows-1252",
+			content:  []byte("term;pos\n\xE9l\xE8ve;n\n"),
+			language: languages.French,
+			words:    []row{{term: "élève", pos: entities.PosNoun}},
+		},
+		{
+			name:     "short row",
+			content:  []byte("term;alt;pos\nchien\n"),
+			language: languages.French,
+			words:    []row{{term: "chien"}},
+		},
+		{
+			name:     "long row",
+			content:  []byte("term;pos\nchien;n;extra\n"),
+			language: languages.French,
+			words:    []row{{term: "chien", pos: entities.PosNoun}},
+			issues:   []CSVIssue{{Line: 2, Message: "3 fields for 2 columns, the extra ones are ignored"}},
+		},
+		{
+			name:     "sniffed tabs with an empty column",
+			content:  []byte("term\talt\tpos\nchien\t\tn\nbeau\tbelle\tadj\n"),
+			language: languages.French,
+			words:    []row{{term: "chien", pos: entities.PosNoun}, {term: "beau", alt: "belle", pos: entities.PosAdjective}},
+		},
+		{
+			name:     "tab delimiter with an empty column",
+			content:  []byte("term\talt\tpos\nchien\t\tn\n"),
+			options:  CSVOptions{Delimiter: '\t'},
+			language: languages.French,
+			words:    []row{{term: "chien", pos: entities.PosNoun}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "words.csv")
+			if err := os.WriteFile(path, test.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			words, report, err := NewCSVRepositoryWithOptions(test.options).ReadWordsFromFile(path, test.language)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]row, 0, len(words))
+			for _, word := range words {
+				got = append(got, row{term: word.Term, pos: word.Morphology.PartOfSpeech, alt: word.TermAlt})
+			}
+			if !slices.Equal(got, test.words) {
+				t.Errorf("words = %+v, want %+v", got, test.words)
+			}
+			if !slices.Equal(report.Issues, test.issues) {
+				t.Errorf("issues = %+v, want %+v", report.Issues, test.issues)
+			}
+		})
+	}
+}
+
+func TestCSVRepositorySaveLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "french.csv")
+	chien := entities.Word{Language: languages.French, Term: "chien", Plural: "chiens", Morphology: entities.ParseMorphology("n")}
+	chien.SetMorphology(entities.Morphology{Gender: entities.GenderMasculine})
+	sous := entities.Word{Language: languages.French, Term: "sous", Morphology: entities.ParseMorphology("préposition")}
+	if err := NewCSVRepository().SaveLegacy(path, []entities.Word{chien, sous}); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "chien;noun masculine\nsous;preposition\n"; string(data) != want {
+		t.Errorf("file = %q, want %q", data, want)
+	}
+	words, _, err := NewCSVRepository().ReadWordsFromFile(path, languages.French)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != 2 || words[0].Morphology.String() != chien.Morphology.String() || words[1].Morphology.String() != sous.Morphology.String() {
+		t.Errorf("words read back = %+v", words)
+	}
+}
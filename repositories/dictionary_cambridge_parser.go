@@ -2,135 +2,364 @@ package repositories
 
 import (
 	"errors"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly"
 	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
 	"github.com/marycka9/go-reverso-api/languages"
-	log "github.com/sirupsen/logrus"
-	"strings"
 )
 
 const (
-	baseUrlCambridge = "https://dictionary.cambridge.org/dictionary/"
+	baseSiteCambridge = "https://dictionary.cambridge.org"
+	baseUrlCambridge  = baseSiteCambridge + "/dictionary/"
 )
 
-type DictionaryCambridgeParser struct{}
+// cambridgeNames holds the languages Cambridge names differently from the registry
+var cambridgeNames = map[languages.Code]string{
+	languages.Chinese: "chinese-simplified",
+}
+
+type DictionaryCambridgeParser struct {
+	// Client downloads the audio files, the pages go through its transport
+	Client *http.Client
+}
+
+// SourceName marks the parser as a dictionary source, see Source
+func (p *DictionaryCambridgeParser) SourceName() string {
+	return "Dictionary Cambridge"
+}
 
 func NewDictionaryCambridgeParser() *DictionaryCambridgeParser {
-	return &DictionaryCambridgeParser{}
+	return &DictionaryCambridgeParser{
+		Client: http.DefaultClient,
+	}
 }
 
-// Extract the translation from French to English
-func fetchTranslationFromFrenchToEnglis(term, partOfSpeech string) ([]string, error) {
+// collector returns a collector fetching the pages through the transport of Client
+func (p *DictionaryCambridgeParser) collector() *colly.Collector {
 	c := colly.NewCollector()
 	c.UserAgent = DefaultUserAgent
+	if p.Client != nil && p.Client.Transport != nil {
+		c.WithTransport(p.Client.Transport)
+	}
+	return c
+}
 
-	var firstMatchFound bool
-	parser := common.GetPartOfSpeechParserInstance()
-	var translations []string
-	var transcription string
-	c.OnHTML("div.pr.dictionary", func(e *colly.HTMLElement) {
-		if firstMatchFound {
-			return
-		}
-		findPartOfSpeech := e.DOM.Find("span.pos.dpos").First().Text()
-		if parser.Parse(findPartOfSpeech) != partOfSpeech {
-			return
+// cambridgeUrl returns the page of the term in the srcLang-dstLang dictionary, or in the English one for en-en
+func cambridgeUrl(term string, srcLang, dstLang *languages.Language) string {
+	name := func(lang *languages.Language) string {
+		if n, ok := cambridgeNames[lang.Code]; ok {
+			return n
 		}
+		return lang.Name
+	}
 
-		//transcription = e.DOM.Find("span.pron.dpron").First().Text()
+	var builder strings.Builder
+	builder.WriteString(baseUrlCambridge)
+	builder.WriteString(name(srcLang))
+	if srcLang.Code != dstLang.Code {
+		builder.WriteRune('-')
+		builder.WriteString(name(dstLang))
+	}
+	builder.WriteRune('/')
+	builder.WriteString(url.PathEscape(strings.ToLower(term)))
+	return builder.String()
+}
+
+// cleanText collapses the whitespace Cambridge pages are full of
+func cleanText(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
 
-		// TODO: When you need several translations of one word, then change here
-		translation := e.DOM.Find("span.trans.dtrans").First().Text()
-		translations = append(translations, translation)
-		log.Infof("partOfSpeach: %s, translation: %s , transcription: %s", findPartOfSpeech, translation, transcription)
+// splitTranslations splits "chien, chienne" into separate translations
+func splitTranslations(s string) []string {
+	res := make([]string, 0)
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if t = cleanText(t); t != "" {
+			res = append(res, t)
+		}
+	}
+	return res
+}
+
+// parseCambridgeEntry extracts the headword, part of speech and senses of an entry block of a dictionary of lang
+func parseCambridgeEntry(s *goquery.Selection, lang languages.Code) entities.DictionaryEntry {
+	entry := entities.DictionaryEntry{
+		Headword: cleanText(s.Find("span.hw.dhw").First().Text()),
+	}
+	if entry.Headword == "" {
+		entry.Headword = cleanText(s.Find(".headword").First().Text())
+	}
 
-		// Set flag, for stopping process
-		firstMatchFound = true
+	header := s.Find("div.pos-header").First()
+	if header.Length() == 0 {
+		header = s
+	}
+	labels := header.Find("span.pos.dpos, span.gram.dgram, span.gc.dgc, span.usage.dusage").Map(func(_ int, l *goquery.Selection) string {
+		return l.Text()
+	})
+	entry.Morphology = entities.ParseMorphologyFor(lang, common.VocabularyCambridge, labels...)
+	header.Find("span.irreg-infls span.inf-group").Each(func(_ int, group *goquery.Selection) {
+		if entry.Plural == "" && strings.Contains(group.Find("span.lab").Text(), "plural") {
+			entry.Plural = cleanText(group.Find("b.inf").First().Text())
+		}
 	})
 
-	var builder strings.Builder
-	builder.WriteString(baseUrlCambridge)
-	builder.WriteString(string(entities.French))
-	builder.WriteRune('-')
-	builder.WriteString(string(entities.English))
-	builder.WriteRune('/')
-	builder.WriteString(term)
+	s.Find("div.def-block.ddef_block").Each(func(_ int, block *goquery.Selection) {
+		guideword := cleanText(block.Closest("div.dsense").Find("span.guideword.dgw").First().Text())
+		sense := entities.DictionarySense{
+			Guideword:  strings.Trim(guideword, "() "),
+			Definition: strings.TrimSuffix(cleanText(block.Find("div.def.ddef_d").First().Text()), ":"),
+			Level:      cleanText(block.Find("span.epp-xref.dxref").First().Text()),
+		}
 
-	err := c.Visit(builder.String())
+		body := block.Find("div.def-body.ddef_b").First()
+		body.ChildrenFiltered("span.trans.dtrans").Each(func(_ int, t *goquery.Selection) {
+			sense.Translations = append(sense.Translations, splitTranslations(t.Text())...)
+		})
+		body.Find("div.examp.dexamp").Each(func(_ int, ex *goquery.Selection) {
+			example := entities.ExamplePair{
+				Source: cleanText(ex.Find("span.eg.deg").First().Text()),
+				Target: cleanText(ex.Find("span.trans.dtrans").First().Text()),
+			}
+			if example.Source != "" {
+				sense.Examples = append(sense.Examples, example)
+			}
+		})
 
-	return translations, err
+		entry.Senses = append(entry.Senses, sense)
+	})
 
+	return entry
 }
 
-func fetchTranscription(term string, srcLang, dstLang entities.Language) (string, error) {
-	c := colly.NewCollector()
-	c.UserAgent = DefaultUserAgent
+// FetchEntries returns every entry of the term with all of its senses
+func (p *DictionaryCambridgeParser) FetchEntries(term string, srcLang, dstLang *languages.Language) ([]entities.DictionaryEntry, error) {
+	if term == "" {
+		return nil, errors.New("term cannot be empty")
+	}
+	if err := languages.CheckPair(languages.ServiceCambridge, srcLang, dstLang); err != nil {
+		return nil, WrapUnsupported(err)
+	}
 
-	var firstMatchFound bool
-	var transcription string
+	c := p.collector()
 
+	var res []entities.DictionaryEntry
 	c.OnHTML("div.pr.dictionary", func(e *colly.HTMLElement) {
-		if firstMatchFound {
-			return
+		entries := e.DOM.Find("div.entry-body__el")
+		if entries.Length() == 0 {
+			entries = e.DOM
 		}
-		transcription = e.DOM.Find("span.pron.dpron").First().Text()
-		// Set flag, for stopping process
-		firstMatchFound = true
+		entries.Each(func(_ int, s *goquery.Selection) {
+			if entry := parseCambridgeEntry(s, srcLang.Code); len(entry.Senses) > 0 {
+				res = append(res, entry)
+			}
+		})
 	})
 
-	var builder strings.Builder
-	builder.WriteString(baseUrlCambridge)
-	builder.WriteString(string(srcLang))
-	builder.WriteRune('-')
-	builder.WriteString(string(dstLang))
-	builder.WriteRune('/')
-	builder.WriteString(term)
+	if err := c.Visit(cambridgeUrl(term, srcLang, dstLang)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
 
-	err := c.Visit(builder.String())
-	return transcription, err
+// parseCambridgePronunciation reads the IPA and the audio sources of a span.dpron-i block
+func parseCambridgePronunciation(s *goquery.Selection) entities.Pronunciation {
+	pronunciation := entities.Pronunciation{
+		IPA: cleanText(s.Find("span.ipa.dipa").First().Text()),
+	}
+	switch {
+	case s.HasClass("uk"):
+		pronunciation.Region = entities.RegionUK
+	case s.HasClass("us"):
+		pronunciation.Region = entities.RegionUS
+	}
+	if src, ok := s.Find(`source[type="audio/mpeg"]`).First().Attr("src"); ok {
+		pronunciation.AudioMP3 = cambridgeMediaUrl(src)
+	}
+	if src, ok := s.Find(`source[type="audio/ogg"]`).First().Attr("src"); ok {
+		pronunciation.AudioOGG = cambridgeMediaUrl(src)
+	}
+	return pronunciation
+}
 
+// cambridgeMediaUrl resolves the site-relative paths of the audio sources
+func cambridgeMediaUrl(src string) string {
+	if strings.HasPrefix(src, "/") {
+		return baseSiteCambridge + src
+	}
+	return src
 }
 
-// Don't use this metod TODO:: refactoring
-func (p *DictionaryCambridgeParser) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *languages.Language) ([]string, error) {
+// FetchPronunciations returns the UK and US pronunciations of the term with their audio URLs, in page order
+func (p *DictionaryCambridgeParser) FetchPronunciations(term string, srcLang, dstLang *languages.Language) ([]entities.Pronunciation, error) {
 	if term == "" {
 		return nil, errors.New("term cannot be empty")
 	}
+	if err := languages.CheckPair(languages.ServiceCambridge, srcLang, dstLang); err != nil {
+		return nil, WrapUnsupported(err)
+	}
+
+	c := p.collector()
+
+	res := make([]entities.Pronunciation, 0)
+	seen := make(map[entities.Pronunciation]bool)
+	c.OnHTML("div.pr.dictionary", func(e *colly.HTMLElement) {
+		e.DOM.Find("span.dpron-i").Each(func(_ int, s *goquery.Selection) {
+			pronunciation := parseCambridgePronunciation(s)
+			if pronunciation.IPA == "" || seen[pronunciation] {
+				return
+			}
+			seen[pronunciation] = true
+			res = append(res, pronunciation)
+		})
+	})
+
+	if err := c.Visit(cambridgeUrl(term, srcLang, dstLang)); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DownloadPronunciations saves the MP3 recording of every pronunciation as dir/<term>_<region>.mp3 and sets its Path,
+// see common.MediaFileName
+func (p *DictionaryCambridgeParser) DownloadPronunciations(term, dir string, pronunciations []entities.Pronunciation) error {
+	var errs []error
+	for i := range pronunciations {
+		pronunciation := &pronunciations[i]
+		if pronunciation.AudioMP3 == "" {
+			continue
+		}
+
+		name := common.MediaFileName(term)
+		if pronunciation.Region != entities.RegionNone {
+			name += "_" + string(pronunciation.Region)
+		}
+		path := filepath.Join(dir, name+".mp3")
+
+		req, err := http.NewRequest(http.MethodGet, pronunciation.AudioMP3, nil)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		req.Header.Add("User-Agent", DefaultUserAgent)
+
+		if err := common.SaveMedia(p.Client, req, path); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pronunciation.Path = path
+	}
+	return errors.Join(errs...)
+}
+
+// FetchTranslations returns the translations of every sense of the entries matching the part of speech
+func (p *DictionaryCambridgeParser) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *languages.Language) ([]string, error) {
 	if partOfSpeech == "" {
 		return nil, errors.New("part_of_speech cannot be empty")
 	}
 
-	var translations []string
-	var err error
-	//if srcLang == entities.French {
-	translations, err = fetchTranslationFromFrenchToEnglis(term, partOfSpeech)
+	entries, err := p.FetchEntries(term, srcLang, dstLang)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]entities.DictionaryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if string(entry.Morphology.PartOfSpeech) == partOfSpeech {
+			matching = append(matching, entry)
+		}
+	}
+	return entities.AllTranslations(matching), nil
+}
+
+// FetchAdditionalData completes the morphology of the word and the plural form of nouns from the entry of its part of speech
+func (p *DictionaryCambridgeParser) FetchAdditionalData(word *entities.Word) error {
+	srcLang, err := languages.Get(word.Language)
+	if err != nil {
+		return err
+	}
+	// Words are looked up in their English dictionary, English ones in the monolingual dictionary
+	dstLang := languages.MustGet(languages.English)
+
+	entries, err := p.FetchEntries(word.Term, srcLang, dstLang)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	entry := entries[0]
+	for _, e := range entries {
+		if e.Morphology.PartOfSpeech == word.Morphology.PartOfSpeech {
+			entry = e
+			break
+		}
+	}
+	word.SetMorphology(entry.Morphology)
+	if entry.Plural != "" && word.Morphology.PartOfSpeech == entities.PosNoun {
+		word.Plural = entry.Plural
+	}
+	return nil
+}
+
+// FetchExamples returns the example sentences of every sense of the term
+func (p *DictionaryCambridgeParser) FetchExamples(term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, error) {
+	entries, err := p.FetchEntries(term, srcLang, dstLang)
 	if err != nil {
-		log.Error("Failed to visit URL:", err)
+		return nil, err
 	}
-	//}
 
-	return translations, nil
+	examples := make([]entities.ExamplePair, 0)
+	for _, entry := range entries {
+		for _, sense := range entry.Senses {
+			examples = append(examples, sense.Examples...)
+		}
+	}
+	return examples, nil
 }
 
-func (p *DictionaryCambridgeParser) FetchTranscription(term string, srcLang, dstLang entities.Language) (string, error) {
+func (p *DictionaryCambridgeParser) FetchTranscription(term string, srcLang, dstLang *languages.Language) (string, error) {
 	if term == "" {
 		return "", errors.New("term cannot be empty")
 	}
-	if srcLang == "" {
-		return "", errors.New("src_lang cannot be empty")
+	if err := languages.CheckPair(languages.ServiceCambridge, srcLang, dstLang); err != nil {
+		return "", WrapUnsupported(err)
 	}
-	if dstLang == "" {
-		return "", errors.New("dst_lang cannot be empty")
+	pronunciations, err := p.FetchPronunciations(term, srcLang, dstLang)
+	if err != nil {
+		return "", err
 	}
-	transcription, err := fetchTranscription(term, srcLang, dstLang)
-	return transcription, err
+	pronunciation, _ := entities.PreferredPronunciation(pronunciations, entities.RegionUK)
+	return pronunciation.Transcription(), nil
 }
 
-func (p *DictionaryCambridgeParser) FetchAdditionalData(word *entities.Word) error {
-	return nil
-}
+// FetchPronunciation saves the Cambridge recording of the term, British when there is one, and returns its path
+func (p *DictionaryCambridgeParser) FetchPronunciation(term string, lang *languages.Language, dir string) (string, error) {
+	if err := languages.CheckLanguage(languages.ServiceCambridge, lang); err != nil {
+		return "", WrapUnsupported(err)
+	}
+	dstLang := languages.MustGet(languages.English)
+	if lang.Code == languages.English {
+		// The monolingual dictionary has both regions, the bilingual ones often only one
+		dstLang = lang
+	}
+
+	pronunciations, err := p.FetchPronunciations(term, lang, dstLang)
+	if err != nil {
+		return "", err
+	}
+	pronunciation, ok := entities.PreferredPronunciation(pronunciations, entities.RegionUK)
+	if !ok || pronunciation.AudioMP3 == "" {
+		return "", nil
+	}
 
-func (p *DictionaryCambridgeParser) FetchConjugation(term string, lang entities.Language) (*entities.FrenchVerbConjugation, error) {
-	return nil, nil
+	downloaded := []entities.Pronunciation{pronunciation}
+	if err := p.DownloadPronunciations(term, dir, downloaded); err != nil {
+		return "", err
+	}
+	return downloaded[0].Path, nil
 }
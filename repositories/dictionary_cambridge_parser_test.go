@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// pageTransport answers the requests for the URLs of pages with the files of testdata and records the URLs
+// requested. Other URLs are not found
+type pageTransport struct {
+	pages     map[string]string
+	requested []string
+}
+
+func (t *pageTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requested = append(t.requested, req.URL.String())
+	name, ok := t.pages[req.URL.String()]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: http.NoBody, Request: req}, nil
+	}
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": []string{"text/html; charset=utf-8"}},
+		Body:       file,
+		Request:    req,
+	}, nil
+}
+
+// newTestCambridgeParser returns a parser reading the English-French pages of testdata
+func newTestCambridgeParser() *DictionaryCambridgeParser {
+	transport := &pageTransport{pages: map[string]string{
+		baseUrlCambridge + "english-french/light":    "cambridge_english-french_light.html",
+		baseUrlCambridge + "english-french/elevator": "cambridge_english-french_elevator.html",
+	}}
+	return &DictionaryCambridgeParser{Client: &http.Client{Transport: transport}}
+}
+
+func TestDictionaryCambridgeParserFetchTranslations(t *testing.T) {
+	tests := []struct {
+		partOfSpeech string
+		want         []string
+	}{
+		{partOfSpeech: "n", want: []string{"lumière", "lampe", "feu"}},
+		{partOfSpeech: "adj", want: []string{"léger"}},
+		{partOfSpeech: "v", want: []string{"allumer"}},
+		{partOfSpeech: "adv", want: []string{}},
+	}
+	english, french := languages.MustGet(languages.English), languages.MustGet(languages.French)
+	for _, test := range tests {
+		t.Run(test.partOfSpeech, func(t *testing.T) {
+			got, err := newTestCambridgeParser().FetchTranslations("Light", test.partOfSpeech, english, french)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("translations = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDictionaryCambridgeParserFetchTranscription(t *testing.T) {
+	tests := []struct {
+		name string
+		term string
+		want string
+	}{
+		// The page lists the US pronunciation first
+		{name: "UK first", term: "light", want: "/laɪt/"},
+		{name: "US only", term: "elevator", want: "/ˈel·əˌveɪ·t̬ər/"},
+	}
+	english, french := languages.MustGet(languages.English), languages.MustGet(languages.French)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := newTestCambridgeParser().FetchTranscription(test.term, english, french)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("transcription = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
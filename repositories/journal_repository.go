@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/entities"
+)
+
+// JournalRepository persists the state of every word of an import as JSON Lines. Each update is appended, the last
+// line of a key wins when the journal is reopened. The journal keeps a word for as long as it exists, so that later
+// imports skip the words already sent to Anki; Compact drops the history of their states
+type JournalRepository struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	entries map[string]entities.JournalEntry
+}
+
+// OpenJournalRepository opens the journal at filePath, creating it if needed, and replays its entries
+func OpenJournalRepository(filePath string) (*JournalRepository, error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]entities.JournalEntry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var offset int64
+	line := 0
+	for scanner.Scan() {
+		line++
+		var entry entities.JournalEntry
+		if len(scanner.Bytes()) > 0 {
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				// A crash can leave the last line half written: cut it off, anything earlier is corruption
+				if scanner.Scan() {
+					_ = file.Close()
+					return nil, fmt.Errorf("journal %s line %d: %w", filePath, line, err)
+				}
+				if err := file.Truncate(offset); err != nil {
+					_ = file.Close()
+					return nil, err
+				}
+				break
+			}
+			entries[entry.Key] = entry
+		}
+		offset += int64(len(scanner.Bytes())) + 1
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	return &JournalRepository{path: filePath, file: file, entries: entries}, nil
+}
+
+// Get returns the entry of the key
+func (r *JournalRepository) Get(key string) (entities.JournalEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[key]
+	return entry, ok
+}
+
+// Entries returns every entry of the journal
+func (r *JournalRepository) Entries() []entities.JournalEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := make([]entities.JournalEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		res = append(res, entry)
+	}
+	return res
+}
+
+// Record appends the entry and syncs it to disk before returning
+func (r *JournalRepository) Record(entry entities.JournalEntry) error {
+	if entry.Key == "" {
+		return errors.New("journal entry key cannot be empty")
+	}
+	entry.UpdatedAt = time.Now().UTC()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := r.file.Sync(); err != nil {
+		return err
+	}
+	r.entries[entry.Key] = entry
+	return nil
+}
+
+// Compact rewrites the journal with the last state of every word only, sorted by key
+func (r *JournalRepository) Compact() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := make([]string, 0, len(r.entries))
+	for key := range r.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	err := writeFile(r.path, func(w io.Writer) error {
+		for _, key := range keys {
+			data, err := json.Marshal(r.entries[key])
+			if err != nil {
+				return err
+			}
+			if _, err := w.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// The file was replaced, the entries recorded from now on go to the new one
+	file, err := os.OpenFile(r.path, os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	_ = r.file.Close()
+	r.file = file
+	return nil
+}
+
+// Close closes the journal file
+func (r *JournalRepository) Close() error {
+	return r.file.Close()
+}
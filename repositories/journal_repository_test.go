@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+)
+
+func TestJournalRepositoryCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "import.journal.jsonl")
+	journal, err := OpenJournalRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = journal.Close() }()
+
+	entries := []entities.JournalEntry{
+		{Key: "fr|chien|n", State: entities.JournalPending, Attempts: 1},
+		{Key: "fr|chien|n", State: entities.JournalEnriched, Attempts: 1},
+		{Key: "fr|chien|n", State: entities.JournalCardCreated, NoteIDs: []int64{7}, Attempts: 1},
+		{Key: "fr|chat|n", State: entities.JournalPending, Attempts: 1},
+		{Key: "fr|chat|n", State: entities.JournalFailed, Error: "larousse: 503", Attempts: 1},
+	}
+	for _, entry := range entries {
+		if err := journal.Record(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := journal.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("%d lines once compacted, want one per word", lines)
+	}
+
+	// Entries recorded after the compaction go to the rewritten file
+	if err := journal.Record(entities.JournalEntry{Key: "fr|chat|n", State: entities.JournalPending, Attempts: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := journal.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenJournalRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = reopened.Close() }()
+	if chien, _ := reopened.Get("fr|chien|n"); chien.State != entities.JournalCardCreated || len(chien.NoteIDs) != 1 {
+		t.Errorf("chien = %+v, want its card and note kept", chien)
+	}
+	if chat, _ := reopened.Get("fr|chat|n"); chat.State != entities.JournalPending || chat.Attempts != 2 {
+		t.Errorf("chat = %+v, want the entry recorded after the compaction", chat)
+	}
+}
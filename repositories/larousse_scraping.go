@@ -1,29 +1,222 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
 	"github.com/marycka9/go-reverso-api/languages"
 	"github.com/serope/laroussefr/traduction"
 )
 
-type LarousseScarping struct{}
+const (
+	baseUrlLarousse            = "https://www.larousse.fr/dictionnaires/"
+	baseUrlLarousseConjugaison = "https://www.larousse.fr/conjugaison/francais/"
+)
+
+// larousseNames holds the names of the languages in the URLs of the Larousse bilingual dictionaries
+var larousseNames = map[languages.Code]string{
+	languages.French:  "francais",
+	languages.English: "anglais",
+	languages.Spanish: "espagnol",
+	languages.German:  "allemand",
+	languages.Italian: "italien",
+}
+
+type LarousseScarping struct {
+	// Client downloads the pages
+	Client *http.Client
+}
+
+// SourceName marks the scraper as a dictionary source, see Source
+func (p *LarousseScarping) SourceName() string {
+	return "Larousse"
+}
 
 func NewLarousseScarping() *LarousseScarping {
-	return &LarousseScarping{}
+	return &LarousseScarping{
+		Client: http.DefaultClient,
+	}
+}
+
+// larousseUrl returns the page of the term in the srcLang-dstLang dictionary
+func larousseUrl(term string, srcLang, dstLang *languages.Language) string {
+	return fmt.Sprintf("%s%s-%s/%s", baseUrlLarousse, larousseNames[srcLang.Code], larousseNames[dstLang.Code],
+		url.PathEscape(strings.ReplaceAll(strings.TrimSpace(term), " ", "-")))
+}
+
+// fetch returns the Larousse page of the term. traduction only accepts the French-English URLs, so every page is
+// downloaded with Client and handed to it as a file
+func (p *LarousseScarping) fetch(term string, srcLang, dstLang *languages.Language) (traduction.Result, error) {
+	if term == "" {
+		return traduction.Result{}, errors.New("term cannot be empty")
+	}
+	if err := languages.CheckPair(languages.ServiceLarousse, srcLang, dstLang); err != nil {
+		return traduction.Result{}, WrapUnsupported(err)
+	}
+
+	page, err := os.CreateTemp("", "larousse-*.html")
+	if err != nil {
+		return traduction.Result{}, err
+	}
+	defer os.Remove(page.Name())
+
+	err = p.download(larousseUrl(term, srcLang, dstLang), page)
+	if closeErr := page.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return traduction.Result{}, err
+	}
+	return traduction.NewFromFileOrURL(page.Name())
+}
+
+func (p *LarousseScarping) download(pageUrl string, w io.Writer) error {
+	req, err := http.NewRequest(http.MethodGet, pageUrl, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", DefaultUserAgent)
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetching %s: unexpected status %s", pageUrl, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// larousseSense turns an item of a Larousse entry into a sense: the meanings give the translations
+// and their bracketed indicators the guideword, the phrases give the examples
+func larousseSense(title string, item traduction.Item) entities.DictionarySense {
+	sense := entities.DictionarySense{}
+	for _, meaning := range item.Meanings {
+		sense.Translations = append(sense.Translations, splitTranslations(meaning.Text)...)
+		if sense.Guideword == "" {
+			sense.Guideword = strings.Trim(cleanText(meaning.RedBrac+" "+meaning.RedCaps), "[]() ")
+		}
+		if sense.Definition == "" {
+			sense.Definition = cleanText(meaning.RedMeta)
+		}
+	}
+	if sense.Guideword == "" {
+		sense.Guideword = strings.Trim(cleanText(title), "[]() ")
+	}
+
+	for _, phrase := range item.Phrases {
+		if phrase.Text1 != "" {
+			sense.Examples = append(sense.Examples, entities.ExamplePair{
+				Source: cleanText(phrase.Text1),
+				Target: cleanText(phrase.Text2),
+			})
+		}
+	}
+	return sense
+}
+
+// FetchEntries returns every entry of the term with its senses grouped as on the Larousse page
+func (p *LarousseScarping) FetchEntries(term string, srcLang, dstLang *languages.Language) ([]entities.DictionaryEntry, error) {
+	result, err := p.fetch(term, srcLang, dstLang)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]entities.DictionaryEntry, 0, len(result.Words))
+	for _, word := range result.Words {
+		entry := entities.DictionaryEntry{
+			Headword:   cleanText(word.Header.Text),
+			Morphology: entities.ParseMorphologyFor(srcLang.Code, common.VocabularyLarousse, word.Header.Type),
+		}
+		for _, subheader := range word.Subheaders {
+			for _, item := range subheader.Items {
+				if sense := larousseSense(subheader.Title, item); len(sense.Translations) > 0 || len(sense.Examples) > 0 {
+					entry.Senses = append(entry.Senses, sense)
+				}
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
 }
 
-// TODO:: refactoring
+// FetchTranslations returns the translations of every sense of the entries matching the part of speech
 func (p *LarousseScarping) FetchTranslations(term, partOfSpeech string, srcLang, dstLang *languages.Language) ([]string, error) {
-	return nil, nil
+	if partOfSpeech == "" {
+		return nil, errors.New("part_of_speech cannot be empty")
+	}
+
+	entries, err := p.FetchEntries(term, srcLang, dstLang)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]entities.DictionaryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if string(entry.Morphology.PartOfSpeech) == partOfSpeech {
+			matching = append(matching, entry)
+		}
+	}
+	return entities.AllTranslations(matching), nil
+}
+
+// FetchExamples returns the phrases of every sense of the term with their translations
+func (p *LarousseScarping) FetchExamples(term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, error) {
+	entries, err := p.FetchEntries(term, srcLang, dstLang)
+	if err != nil {
+		return nil, err
+	}
+
+	examples := make([]entities.ExamplePair, 0)
+	for _, entry := range entries {
+		for _, sense := range entry.Senses {
+			examples = append(examples, sense.Examples...)
+		}
+	}
+	return examples, nil
 }
 
-func (p *LarousseScarping) FetchTranscription(term string, srcLang, dstLang entities.Language) (string, error) {
+// FetchTranscription returns the phonetics of the first entry having some
+func (p *LarousseScarping) FetchTranscription(term string, srcLang, dstLang *languages.Language) (string, error) {
+	result, err := p.fetch(term, srcLang, dstLang)
+	if err != nil {
+		return "", err
+	}
+	for _, word := range result.Words {
+		if word.Header.Phonetic != "" {
+			return word.Header.Phonetic, nil
+		}
+	}
 	return "", nil
 }
 
+// larousseTarget returns the language the dictionary of the word is looked up in: every Larousse dictionary
+// pairs with French, French words are looked up in the English one
+func larousseTarget(lang languages.Code) *languages.Language {
+	if lang == languages.French {
+		return languages.MustGet(languages.English)
+	}
+	return languages.MustGet(languages.French)
+}
+
 func (p *LarousseScarping) FetchAdditionalData(word *entities.Word) error {
+	srcLang, err := languages.Get(word.Language)
+	if err != nil {
+		return err
+	}
 
-	result, err := traduction.New(word.Term, traduction.Fr, traduction.En)
+	result, err := p.fetch(word.Term, srcLang, larousseTarget(word.Language))
 	if err != nil {
 		return err
 	}
@@ -38,12 +231,91 @@ func (p *LarousseScarping) FetchAdditionalData(word *entities.Word) error {
 		if result.Words[0].Header.Phonetic != "" {
 			word.Transcription = result.Words[0].Header.Phonetic
 		}
-		word.Type = result.Words[0].Header.Type
+		word.SetMorphology(entities.ParseMorphologyFor(word.Language, common.VocabularyLarousse, result.Words[0].Header.Type))
+		if word.Plural == "" {
+			word.Plural = laroussePlural(word)
+		}
 	}
 
 	return nil
 }
 
-func (p *LarousseScarping) FetchConjugation(term string, lang entities.Language) (*entities.FrenchVerbConjugation, error) {
-	return nil, nil
+// laroussePlural returns the plural of a French noun from the number Larousse gives it: an invariable noun keeps
+// its form, a plural one is its own plural, the others follow common.FrenchPlural. Larousse shows no plural forms
+func laroussePlural(word *entities.Word) string {
+	if word.Language != languages.French || word.Morphology.PartOfSpeech != entities.PosNoun {
+		return ""
+	}
+	switch word.Morphology.Number {
+	case entities.NumberInvariable, entities.NumberPlural:
+		return word.Term
+	}
+	return common.FrenchPlural(word.Term)
+}
+
+// FetchConjugation returns the indicative and imperative tenses of a French verb from the Larousse conjugation tables
+func (p *LarousseScarping) FetchConjugation(term string, lang *languages.Language) (*entities.FrenchVerbConjugation, error) {
+	if lang == nil || lang.Code != languages.French {
+		name := "nil"
+		if lang != nil {
+			name = lang.Name
+		}
+		return nil, fmt.Errorf("%s conjugation: %w: %w: %s", languages.ServiceLarousse, ErrUnsupported, languages.ErrUnsupportedLanguage, name)
+	}
+	if term == "" {
+		return nil, errors.New("term cannot be empty")
+	}
+
+	var page strings.Builder
+	if err := p.download(baseUrlLarousseConjugaison+url.PathEscape(strings.ToLower(term)), &page); err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	conjugation := &entities.FrenchVerbConjugation{
+		Infinitif: term,
+		Indicatif: make(map[string][]string),
+		Imperatif: make(map[string][]string),
+	}
+
+	// Every mode is an h2 followed by one div.conjugaison per tense, each with an h3 title and a form per line
+	doc.Find("h2").Each(func(_ int, heading *goquery.Selection) {
+		var tenses map[string][]string
+		switch mode := strings.ToLower(cleanText(heading.Text())); {
+		case strings.HasPrefix(mode, "indicatif"):
+			tenses = conjugation.Indicatif
+		case strings.HasPrefix(mode, "impératif"):
+			tenses = conjugation.Imperatif
+		default:
+			return
+		}
+
+		heading.NextUntil("h2").Find("div.conjugaison").AddSelection(heading.NextUntil("h2").Filter("div.conjugaison")).
+			Each(func(_ int, table *goquery.Selection) {
+				tense := cleanText(table.Find("h3").First().Text())
+				if tense == "" {
+					return
+				}
+				table.Find("h3").Remove()
+				table.Find("br").ReplaceWithHtml("\n")
+
+				forms := make([]string, 0)
+				for _, line := range strings.Split(table.Text(), "\n") {
+					if form := cleanText(line); form != "" {
+						forms = append(forms, form)
+					}
+				}
+				if len(forms) > 0 {
+					tenses[tense] = forms
+				}
+			})
+	})
+
+	if len(conjugation.Indicatif) == 0 {
+		return nil, fmt.Errorf("no Larousse conjugation for the verb %s", term)
+	}
+	return conjugation, nil
 }
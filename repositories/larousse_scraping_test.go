@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// newTestLarousse returns a scraper reading the pages of testdata and the transport serving them
+func newTestLarousse() (*LarousseScarping, *pageTransport) {
+	transport := &pageTransport{pages: map[string]string{
+		baseUrlLarousse + "francais-anglais/chien": "larousse_francais-anglais_chien.html",
+		baseUrlLarousse + "francais-espagnol/chat": "larousse_francais-espagnol_chat.html",
+		baseUrlLarousseConjugaison + "aller":       "larousse_conjugaison_aller.html",
+	}}
+	return &LarousseScarping{Client: &http.Client{Transport: transport}}, transport
+}
+
+func TestLarousseScarpingFetchAdditionalData(t *testing.T) {
+	larousse, _ := newTestLarousse()
+	word := &entities.Word{Language: languages.French, Term: "chien"}
+	if err := larousse.FetchAdditionalData(word); err != nil {
+		t.Fatal(err)
+	}
+	if word.Term != "chien" || word.TermAlt != "chienne" || word.Transcription != "[ʃjɛ̃, ʃjɛn]" {
+		t.Errorf("word = %s (%s) %s, want chien (chienne) [ʃjɛ̃, ʃjɛn]", word.Term, word.TermAlt, word.Transcription)
+	}
+	if word.Morphology.PartOfSpeech != entities.PosNoun || word.Morphology.Gender != entities.GenderMasculineFeminine {
+		t.Errorf("morphology = %+v, want a noun of both genders", word.Morphology)
+	}
+	if word.Plural != "chiens" {
+		t.Errorf("plural = %q, want chiens", word.Plural)
+	}
+}
+
+func TestLarousseScarpingFetchTranslationsOtherPair(t *testing.T) {
+	// traduction does not accept the URLs of this dictionary, the page is read from a temporary file removed after
+	t.Setenv("TMPDIR", t.TempDir())
+	larousse, transport := newTestLarousse()
+	got, err := larousse.FetchTranslations("chat", "n", languages.MustGet(languages.French), languages.MustGet(languages.Spanish))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"gato", "gata"}) {
+		t.Errorf("translations = %q, want gato, gata", got)
+	}
+	if want := baseUrlLarousse + "francais-espagnol/chat"; !slices.Equal(transport.requested, []string{want}) {
+		t.Errorf("requested %q, want %s", transport.requested, want)
+	}
+	if files, _ := os.ReadDir(os.TempDir()); len(files) > 0 {
+		t.Errorf("%d files left in the temporary directory", len(files))
+	}
+}
+
+func TestLarousseScarpingFetchConjugation(t *testing.T) {
+	larousse, _ := newTestLarousse()
+	verb, err := larousse.FetchConjugation("aller", languages.MustGet(languages.French))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"Présent":   {"je vais", "tu vas", "il/elle va", "nous allons", "vous allez", "ils/elles vont"},
+		"Imparfait": {"j'allais", "tu allais", "il/elle allait", "nous allions", "vous alliez", "ils/elles allaient"},
+	}
+	if len(verb.Indicatif) != len(want) {
+		t.Errorf("indicative tenses = %q, want %d", verb.Indicatif, len(want))
+	}
+	for tense, forms := range want {
+		if !slices.Equal(verb.Indicatif[tense], forms) {
+			t.Errorf("indicative %s = %q, want %q", tense, verb.Indicatif[tense], forms)
+		}
+	}
+	if imperative := verb.Imperatif["Présent"]; !slices.Equal(imperative, []string{"va", "allons", "allez"}) || len(verb.Imperatif) != 1 {
+		t.Errorf("imperative = %q, want the present only", verb.Imperatif)
+	}
+
+	if _, err := larousse.FetchConjugation("venir", languages.MustGet(languages.French)); err == nil {
+		t.Error("no error for a page not found")
+	}
+}
+
+func TestLaroussePlural(t *testing.T) {
+	tests := []struct {
+		name string
+		word entities.Word
+		want string
+	}{
+		{name: "regular", word: entities.Word{Language: languages.French, Term: "chien", Morphology: entities.Morphology{PartOfSpeech: entities.PosNoun}}, want: "chiens"},
+		{name: "-al", word: entities.Word{Language: languages.French, Term: "cheval", Morphology: entities.Morphology{PartOfSpeech: entities.PosNoun}}, want: "chevaux"},
+		{name: "invariable", word: entities.Word{Language: languages.French, Term: "souris", Morphology: entities.Morphology{PartOfSpeech: entities.PosNoun, Number: entities.NumberInvariable}}, want: "souris"},
+		{name: "plural", word: entities.Word{Language: languages.French, Term: "gens", Morphology: entities.Morphology{PartOfSpeech: entities.PosNoun, Number: entities.NumberPlural}}, want: "gens"},
+		{name: "not a noun", word: entities.Word{Language: languages.French, Term: "grand", Morphology: entities.Morphology{PartOfSpeech: entities.PosAdjective}}, want: ""},
+		{name: "not French", word: entities.Word{Language: languages.English, Term: "dog", Morphology: entities.Morphology{PartOfSpeech: entities.PosNoun}}, want: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := laroussePlural(&test.word); got != test.want {
+				t.Errorf("plural = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package repositories
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// languageColumns are the columns a parallel file can give for each language, as "<column>_<code>", e.g. gender_fr
+var languageColumns = []string{ColumnAlt, ColumnPos, ColumnGender, ColumnPlural, ColumnTranscription, ColumnExample, ColumnExamples}
+
+// parallelColumn is a column of a parallel file: a shared one, the terms of a language, or a column of a language
+type parallelColumn struct {
+	column   string
+	language languages.Code
+}
+
+// ParallelRepository reads and writes parallel vocabulary files: one row per concept, one column per language
+// named by its code or its name, each holding the terms of the concept separated by '|'. The concept, pos, tags,
+// deck and notes columns are shared by the row; the columns of languageColumns can be given for a language
+//
+//	concept;pos;fr;gender_fr;en;ru;tags
+//	12;noun;chien;m;dog;собака|пёс;animals
+type ParallelRepository struct {
+	options CSVOptions
+}
+
+func NewParallelRepository() *ParallelRepository {
+	return NewParallelRepositoryWithOptions(DefaultCSVOptions())
+}
+
+func NewParallelRepositoryWithOptions(options CSVOptions) *ParallelRepository {
+	return &ParallelRepository{
+		options: options,
+	}
+}
+
+// ReadWordsFromFile returns the words of every language of the file. The words of a row share its concept, the
+// concept column or the file name and the line when it has none, see usecases.WordTranslator
+func (r *ParallelRepository) ReadWordsFromFile(filePath string) (map[languages.Code][]entities.Word, *CSVReport, error) {
+	report := &CSVReport{Path: filePath}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, report, err
+	}
+	defer file.Close()
+
+	reader, err := newCSVReader(file, r.options)
+	if err != nil {
+		return nil, report, err
+	}
+
+	wordsByLanguage := make(map[languages.Code][]entities.Word)
+	var columns []parallelColumn
+	var codes []languages.Code
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return wordsByLanguage, report, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			report.Rows++
+			report.add(parseErr.StartLine, "", parseErr.Err.Error(), true)
+			continue
+		}
+		if err != nil {
+			return nil, report, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if columns == nil {
+			if columns, codes, err = parseParallelHeader(record, line, report); err != nil {
+				return nil, report, fmt.Errorf("%s: %w", filePath, err)
+			}
+			if len(codes) == 0 {
+				return nil, report, fmt.Errorf("%s: the header names no language", filePath)
+			}
+			continue
+		}
+
+		report.Rows++
+		words := parseParallelRow(record, columns, codes, line, report)
+		if len(words) == 0 {
+			report.add(line, "", "no term in any language", true)
+			continue
+		}
+		for _, word := range words {
+			if word.Concept == "" {
+				word.Concept = fmt.Sprintf("%s:%d", filepath.Base(filePath), line)
+			}
+			wordsByLanguage[word.Language] = append(wordsByLanguage[word.Language], word)
+		}
+		report.Imported++
+	}
+}
+
+// parseParallelHeader returns the columns of the header and the languages, in the order of their columns.
+// The language of a word is the column of its term, a language column would override it for the whole row
+func parseParallelHeader(record []string, line int, report *CSVReport) ([]parallelColumn, []languages.Code, error) {
+	columns := make([]parallelColumn, len(record))
+	codes := make([]languages.Code, 0)
+	for i, name := range record {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if column, ok := columnAliases[name]; ok {
+			if column == ColumnLanguage {
+				return nil, nil, fmt.Errorf("column %q: the languages of a parallel file are its term columns", name)
+			}
+			if column == ColumnID {
+				column = ColumnConcept
+			}
+			columns[i] = parallelColumn{column: column}
+			continue
+		}
+		if lang, err := languages.Lookup(name); err == nil {
+			columns[i] = parallelColumn{column: ColumnTerm, language: lang.Code}
+			if !slices.Contains(codes, lang.Code) {
+				codes = append(codes, lang.Code)
+			}
+			continue
+		}
+		if column, code, ok := cutLanguageSuffix(name); ok {
+			if lang, err := languages.Lookup(code); err == nil && slices.Contains(languageColumns, column) {
+				columns[i] = parallelColumn{column: column, language: lang.Code}
+				continue
+			}
+		}
+		// Unknown columns are kept empty so their values are ignored
+		report.add(line, name, "unknown column ignored", false)
+	}
+	return columns, codes, nil
+}
+
+// cutLanguageSuffix splits a column of a language, such as gender_fr or alt.en
+func cutLanguageSuffix(name string) (column, code string, ok bool) {
+	i := strings.LastIndexAny(name, "_.")
+	if i < 0 {
+		return "", "", false
+	}
+	column, ok = columnAliases[name[:i]]
+	return column, name[i+1:], ok
+}
+
+// parseParallelRow returns the words of the row, one per term of every language. The cells of each language
+// are read by parseRow as a row of its own, the columns of the language after the shared ones
+func parseParallelRow(record []string, columns []parallelColumn, codes []languages.Code, line int, report *CSVReport) []entities.Word {
+	if len(record) > len(columns) {
+		report.add(line, "", fmt.Sprintf("%d fields for %d columns, the extra ones are ignored", len(record), len(columns)), false)
+		record = record[:len(columns)]
+	}
+
+	var concept string
+	shared := make([]string, 0, len(record))
+	sharedColumns := make([]string, 0, len(record))
+	for i, value := range record {
+		switch {
+		case columns[i].column == ColumnConcept:
+			concept = strings.TrimSpace(value)
+		case columns[i].column != "" && columns[i].language == "":
+			shared = append(shared, value)
+			sharedColumns = append(sharedColumns, columns[i].column)
+		}
+	}
+
+	words := make([]entities.Word, 0)
+	for _, code := range codes {
+		terms := make([]string, 0)
+		cells := slices.Clone(shared)
+		cellColumns := slices.Clone(sharedColumns)
+		for i, value := range record {
+			switch {
+			case columns[i].language != code:
+			case columns[i].column == ColumnTerm:
+				terms = append(terms, splitList(value, "|")...)
+			case strings.TrimSpace(value) != "":
+				cells = append(cells, value)
+				cellColumns = append(cellColumns, columns[i].column)
+			}
+		}
+
+		for _, term := range terms {
+			word, ok := parseRow(append([]string{term}, cells...), append([]string{ColumnTerm}, cellColumns...), false, code, line, report)
+			if !ok {
+				continue
+			}
+			word.Concept = concept
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Load reads the words of every language of the file, failing when rows are skipped like CSVRepository.Load
+func (r *ParallelRepository) Load(filePath string) ([]entities.Word, error) {
+	wordsByLanguage, report, err := r.ReadWordsFromFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if report.Skipped() > 0 {
+		return nil, errors.New(report.String())
+	}
+
+	codes := make([]languages.Code, 0, len(wordsByLanguage))
+	for code := range wordsByLanguage {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	words := make([]entities.Word, 0)
+	for _, code := range codes {
+		words = append(words, wordsByLanguage[code]...)
+	}
+	return words, nil
+}
+
+// Save writes a row per concept, in the order the concepts first appear; words without a concept get a row of
+// their own. The part of speech is taken from the first word of the row, the columns of a language from its first
+// word, the other terms of the language only give their term, as do the translations of the vocabulary files that
+// name no word of the file: the words they name have a row already. Columns no word fills are left out
+func (r *ParallelRepository) Save(filePath string, words []entities.Word) error {
+	rows := make([]map[parallelColumn]string, 0)
+	concepts := make(map[string]int)
+	codes := make([]languages.Code, 0)
+	rowOf := make([]int, len(words))
+	for w, word := range words {
+		i, ok := concepts[word.Concept]
+		if !ok || word.Concept == "" {
+			i = len(rows)
+			concepts[word.Concept] = i
+			rows = append(rows, parallelRow(word))
+		}
+		rowOf[w] = i
+		addParallelWord(rows[i], word)
+		if !slices.Contains(codes, word.Language) {
+			codes = append(codes, word.Language)
+		}
+	}
+	saved := make(map[languages.Code]map[string]bool)
+	for _, word := range words {
+		if saved[word.Language] == nil {
+			saved[word.Language] = make(map[string]bool)
+		}
+		saved[word.Language][strings.ToLower(word.Term)] = true
+	}
+	// The translations the vocabulary file gave are terms of the concept the other words did not bring
+	for w, word := range words {
+		for code, translations := range word.Translations {
+			if word.Sources[entities.TranslationsField(code)] != entities.SourceFile {
+				continue
+			}
+			cell := parallelColumn{column: ColumnTerm, language: code}
+			for _, translation := range translations {
+				if saved[code][strings.ToLower(translation)] {
+					continue
+				}
+				terms := splitList(rows[rowOf[w]][cell], "|")
+				if slices.ContainsFunc(terms, func(term string) bool { return strings.EqualFold(term, translation) }) {
+					continue
+				}
+				rows[rowOf[w]][cell] = strings.Join(append(terms, translation), "|")
+			}
+			if !slices.Contains(codes, code) {
+				codes = append(codes, code)
+			}
+		}
+	}
+	slices.Sort(codes)
+
+	header := []parallelColumn{{column: ColumnConcept}, {column: ColumnPos}}
+	for _, code := range codes {
+		header = append(header, parallelColumn{column: ColumnTerm, language: code})
+		for _, column := range languageColumns {
+			header = append(header, parallelColumn{column: column, language: code})
+		}
+	}
+	header = append(header, parallelColumn{column: ColumnTags}, parallelColumn{column: ColumnDeck}, parallelColumn{column: ColumnNotes})
+	header = slices.DeleteFunc(header, func(column parallelColumn) bool {
+		return column.column != ColumnTerm && !slices.ContainsFunc(rows, func(row map[parallelColumn]string) bool {
+			return row[column] != ""
+		})
+	})
+
+	delimiter := r.options.Delimiter
+	if delimiter == 0 {
+		delimiter = ';'
+	}
+	return writeFile(filePath, func(w io.Writer) error {
+		writer := csv.NewWriter(w)
+		writer.Comma = delimiter
+		names := make([]string, 0, len(header))
+		for _, column := range header {
+			switch {
+			case column.language == "":
+				names = append(names, column.column)
+			case column.column == ColumnTerm:
+				names = append(names, string(column.language))
+			default:
+				names = append(names, column.column+"_"+string(column.language))
+			}
+		}
+		if err := writer.Write(names); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			record := make([]string, 0, len(header))
+			for _, column := range header {
+				record = append(record, row[column])
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	})
+}
+
+// parallelRow returns the shared columns of the row the word starts
+func parallelRow(word entities.Word) map[parallelColumn]string {
+	morphology := word.Morphology
+	morphology.Gender = entities.GenderNone
+	return map[parallelColumn]string{
+		{column: ColumnConcept}: word.Concept,
+		{column: ColumnPos}:     morphology.String(),
+	}
+}
+
+// addParallelWord adds the word to the columns of its language in the row, its tags to the ones of the row
+// and its deck and notes when the row has none
+func addParallelWord(row map[parallelColumn]string, word entities.Word) {
+	tags := parallelColumn{column: ColumnTags}
+	for _, tag := range word.Tags {
+		if !slices.Contains(splitList(row[tags], " "), tag) {
+			row[tags] = strings.TrimSpace(row[tags] + " " + tag)
+		}
+	}
+	for column, value := range map[string]string{ColumnDeck: word.Deck, ColumnNotes: word.Notes} {
+		if row[parallelColumn{column: column}] == "" {
+			row[parallelColumn{column: column}] = value
+		}
+	}
+
+	term := parallelColumn{column: ColumnTerm, language: word.Language}
+	if row[term] != "" {
+		row[term] += "|" + word.Term
+		return
+	}
+	row[term] = word.Term
+
+	morphology := word.Morphology
+	morphology.Gender = entities.GenderNone
+	values := map[string]string{
+		ColumnAlt:           word.TermAlt,
+		ColumnGender:        string(word.Morphology.Gender),
+		ColumnPlural:        word.Plural,
+		ColumnTranscription: word.Transcription,
+		ColumnExamples:      formatExamples(word.Examples),
+	}
+	// The part of speech is only repeated for the languages it differs in
+	if pos := morphology.String(); pos != row[parallelColumn{column: ColumnPos}] {
+		values[ColumnPos] = pos
+	}
+	for column, value := range values {
+		row[parallelColumn{column: column, language: word.Language}] = value
+	}
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParallelRepositoryLanguageColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocabulary.csv")
+	if err := os.WriteFile(path, []byte("concept;language;fr;ru\n1;fr;chien;собака\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := NewParallelRepository().ReadWordsFromFile(path); err == nil {
+		t.Error("no error for a language column overriding the language of every word")
+	}
+}
@@ -1,14 +1,57 @@
 package repositories
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/marycka9/go-reverso-api/entities"
 	"github.com/marycka9/go-reverso-api/languages"
 )
 
-// TranslationFetcher defines an interface for fetching translations from a source
-type TranslationFetcher interface {
+// ErrUnsupported is returned when a source lacks a capability or does not handle the requested language
+var ErrUnsupported = errors.New("unsupported by source")
+
+// WrapUnsupported marks an error of a source refusing a language, languages.ErrUnsupportedLanguage, as ErrUnsupported.
+// Other errors are returned as they are
+func WrapUnsupported(err error) error {
+	if errors.Is(err, languages.ErrUnsupportedLanguage) && !errors.Is(err, ErrUnsupported) {
+		return fmt.Errorf("%w: %w", ErrUnsupported, err)
+	}
+	return err
+}
+
+// Source is a dictionary source. What it can do is discovered by asserting the capability interfaces below
+type Source interface {
+	// SourceName returns the name of the source, for logs and errors
+	SourceName() string
+}
+
+// Translator fetches translations of a term
+type Translator interface {
 	FetchTranslations(term, partOfSpeech string, srcLang, dstLang *languages.Language) ([]string, error)
-	FetchTranscription(term string, srcLang, dstLang entities.Language) (string, error)
+}
+
+// Transcriber fetches the phonetic transcription of a term
+type Transcriber interface {
+	FetchTranscription(term string, srcLang, dstLang *languages.Language) (string, error)
+}
+
+// Enricher completes a word with whatever the source knows about it
+type Enricher interface {
 	FetchAdditionalData(word *entities.Word) error
-	FetchConjugation(term string, lang entities.Language) (*entities.FrenchVerbConjugation, error)
+}
+
+// Conjugator fetches the conjugation of a verb
+type Conjugator interface {
+	FetchConjugation(term string, lang *languages.Language) (*entities.FrenchVerbConjugation, error)
+}
+
+// ExampleProvider fetches example sentences using a term, along with their translations
+type ExampleProvider interface {
+	FetchExamples(term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, error)
+}
+
+// Pronouncer saves a pronunciation of a term under dir and returns the path of the audio file
+type Pronouncer interface {
+	FetchPronunciation(term string, lang *languages.Language, dir string) (string, error)
 }
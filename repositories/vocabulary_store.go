@@ -0,0 +1,375 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// VocabularySchema is the version of the records written by VocabularyStore
+const VocabularySchema = 1
+
+// vocabularyMigrations upgrade a record of the schema of their index to the next one, when a store is opened
+var vocabularyMigrations = []func(record json.RawMessage) (json.RawMessage, error){
+	// 0 is a file of words written by JSONLinesRepository, every word becomes an entry added now
+	func(record json.RawMessage) (json.RawMessage, error) {
+		var word entities.Word
+		if err := json.Unmarshal(record, &word); err != nil {
+			return nil, err
+		}
+		now := time.Now().UTC()
+		entry := entities.VocabularyEntry{Key: word.Key(), Word: word, AddedAt: now, UpdatedAt: now}
+		return json.Marshal(vocabularyRecord{Key: entry.Key, Entry: &entry})
+	},
+}
+
+// vocabularyHeader is the first line of a store
+type vocabularyHeader struct {
+	Schema int `json:"schema"`
+}
+
+// vocabularyRecord is a line of a store, a record without entry deletes the key
+type vocabularyRecord struct {
+	Key   string                    `json:"key"`
+	Entry *entities.VocabularyEntry `json:"entry,omitempty"`
+}
+
+// VocabularyStore is the vocabulary with everything known about its words, kept in a single file. Like
+// JournalRepository it appends every change as a JSON line and replays them when opened, so the file is also the
+// history of the vocabulary until it is compacted
+type VocabularyStore struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	entries map[string]entities.VocabularyEntry
+}
+
+// OpenVocabularyStore opens the store at filePath, creating it if needed. Records of an older schema are migrated
+// and the file rewritten in the current one
+func OpenVocabularyStore(filePath string) (*VocabularyStore, error) {
+	store := &VocabularyStore{path: filePath, entries: make(map[string]entities.VocabularyEntry)}
+
+	rewrite, err := store.replay()
+	if err != nil {
+		return nil, err
+	}
+	if rewrite {
+		if err := store.write(); err != nil {
+			return nil, err
+		}
+	}
+
+	if store.file, err = os.OpenFile(filePath, os.O_WRONLY|os.O_APPEND, 0644); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// replay loads the records of the file. rewrite is set when the file must be written again: it is new,
+// of an older schema, or ends with a line a crash left half written
+func (s *VocabularyStore) replay() (rewrite bool, err error) {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	schema := -1
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		if schema < 0 {
+			if header, ok := parseVocabularyHeader(data); ok {
+				if header.Schema > VocabularySchema {
+					return false, fmt.Errorf("vocabulary store %s: schema %d is newer than %d", s.path, header.Schema, VocabularySchema)
+				}
+				schema = header.Schema
+				continue
+			}
+			// Files without a header are the word files the first schema migrates from
+			schema = 0
+		}
+
+		if err := s.load(data, schema); err != nil {
+			// A crash can leave the last line half written, the rewrite drops it. Anything earlier is corruption
+			if scanner.Scan() {
+				return false, fmt.Errorf("vocabulary store %s line %d: %w", s.path, line, err)
+			}
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	return schema != VocabularySchema, nil
+}
+
+// parseVocabularyHeader reads the line as a header, an object holding the schema only. Anything else, such as a
+// word of a file without header, is a record
+func parseVocabularyHeader(data []byte) (vocabularyHeader, bool) {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil || len(fields) != 1 || fields["schema"] == nil {
+		return vocabularyHeader{}, false
+	}
+	var header vocabularyHeader
+	if json.Unmarshal(data, &header) != nil {
+		return vocabularyHeader{}, false
+	}
+	return header, true
+}
+
+// load migrates a record of the schema to the current one and applies it
+func (s *VocabularyStore) load(data json.RawMessage, schema int) error {
+	var err error
+	for version := schema; version < VocabularySchema; version++ {
+		if data, err = vocabularyMigrations[version](data); err != nil {
+			return fmt.Errorf("migrating from schema %d: %w", version, err)
+		}
+	}
+
+	var record vocabularyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return err
+	}
+	if record.Key == "" {
+		return errors.New("record key cannot be empty")
+	}
+	if record.Entry == nil {
+		delete(s.entries, record.Key)
+	} else {
+		s.entries[record.Key] = *record.Entry
+	}
+	return nil
+}
+
+// write replaces the file with the header and one record per entry, sorted by key
+func (s *VocabularyStore) write() error {
+	keys := make([]string, 0, len(s.entries))
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return writeFile(s.path, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(vocabularyHeader{Schema: VocabularySchema}); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			entry := s.entries[key]
+			if err := encoder.Encode(vocabularyRecord{Key: key, Entry: &entry}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// append writes the record and syncs it to disk, the caller holds the lock
+func (s *VocabularyStore) append(record vocabularyRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	if record.Entry == nil {
+		delete(s.entries, record.Key)
+	} else {
+		s.entries[record.Key] = *record.Entry
+	}
+	return nil
+}
+
+// Get returns the entry of the key, see entities.Word.Key
+func (s *VocabularyStore) Get(key string) (entities.VocabularyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	return entry, ok
+}
+
+// Find returns the entries selected by the query, in the order they were added
+func (s *VocabularyStore) Find(query entities.VocabularyQuery) []entities.VocabularyEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]entities.VocabularyEntry, 0)
+	for _, entry := range s.entries {
+		if query.Match(entry) {
+			res = append(res, entry)
+		}
+	}
+	slices.SortFunc(res, func(a, b entities.VocabularyEntry) int {
+		if c := a.AddedAt.Compare(b.AddedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	return res
+}
+
+// Words returns the words selected by the query, in the order they were added
+func (s *VocabularyStore) Words(query entities.VocabularyQuery) []entities.Word {
+	entries := s.Find(query)
+	words := make([]entities.Word, 0, len(entries))
+	for _, entry := range entries {
+		words = append(words, entry.Word)
+	}
+	return words
+}
+
+// SaveWord adds the word or updates its entry. A source of word.Sources is recorded as an enrichment fetched now
+// when the fields it provided changed, the sources the word no longer names are removed
+func (s *VocabularyStore) SaveWord(word entities.Word) error {
+	key := word.Key()
+	if key == "" {
+		return errors.New("word key cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	entry, ok := s.entries[key]
+	if ok && reflect.DeepEqual(entry.Word, word) {
+		return nil
+	}
+	if !ok {
+		entry = entities.VocabularyEntry{Key: key, AddedAt: now}
+	}
+	previous := entry.Word
+	entry.Word = word
+	entry.UpdatedAt = now
+
+	fields := make(map[string][]string)
+	for field, source := range word.Sources {
+		fields[source] = append(fields[source], field)
+	}
+	enrichments := make(map[string]entities.Enrichment, len(fields))
+	for source, names := range fields {
+		sort.Strings(names)
+		enrichment, ok := entry.Enrichments[source]
+		if !ok || !slices.Equal(enrichment.Fields, names) || !sameSourceFields(previous, word, names) {
+			enrichment = entities.Enrichment{Fields: names, FetchedAt: now}
+		}
+		enrichments[source] = enrichment
+	}
+	entry.Enrichments = nil
+	if len(enrichments) > 0 {
+		entry.Enrichments = enrichments
+	}
+	return s.append(vocabularyRecord{Key: key, Entry: &entry})
+}
+
+// sameSourceFields reports whether the fields, named as in entities.Word.Sources, hold the same values in both
+// words and come from the same source
+func sameSourceFields(a, b entities.Word, fields []string) bool {
+	for _, field := range fields {
+		if a.Sources[field] != b.Sources[field] || !reflect.DeepEqual(sourceFieldValue(a, field), sourceFieldValue(b, field)) {
+			return false
+		}
+	}
+	return true
+}
+
+// sourceFieldValue returns what the field of entities.Word.Sources holds in the word. Additional data covers
+// everything the dictionaries complete a word with
+func sourceFieldValue(word entities.Word, field string) any {
+	switch field {
+	case entities.FieldTranscription:
+		return word.Transcription
+	case entities.FieldAdditionalData:
+		return []any{word.Term, word.TermAlt, word.Transcription, word.Morphology, word.Article, word.Plural, word.Examples}
+	}
+	if lang, ok := strings.CutPrefix(field, entities.FieldTranslations+"."); ok {
+		return word.Translations[languages.Code(lang)]
+	}
+	return nil
+}
+
+// update applies fn to the entry of the key and records it
+func (s *VocabularyStore) update(key string, fn func(entry *entities.VocabularyEntry)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return fmt.Errorf("no vocabulary entry %q", key)
+	}
+	fn(&entry)
+	entry.UpdatedAt = time.Now().UTC()
+	return s.append(vocabularyRecord{Key: key, Entry: &entry})
+}
+
+// SetNoteIDs records the Anki notes created for the word of the key
+func (s *VocabularyStore) SetNoteIDs(key string, ids []int64) error {
+	return s.update(key, func(entry *entities.VocabularyEntry) {
+		entry.NoteIDs = slices.Clone(ids)
+	})
+}
+
+// AddAudio records a recording of the word of the key, replacing the one of the same region
+func (s *VocabularyStore) AddAudio(key string, pronunciation entities.Pronunciation) error {
+	return s.update(key, func(entry *entities.VocabularyEntry) {
+		entry.Audio = slices.DeleteFunc(entry.Audio, func(p entities.Pronunciation) bool {
+			return p.Region == pronunciation.Region
+		})
+		entry.Audio = append(entry.Audio, pronunciation)
+	})
+}
+
+// Delete removes the entry of the key
+func (s *VocabularyStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[key]; !ok {
+		return nil
+	}
+	return s.append(vocabularyRecord{Key: key})
+}
+
+// Compact rewrites the file with the current entries only, dropping the history of their changes
+func (s *VocabularyStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	err := s.write()
+	file, openErr := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if openErr != nil {
+		return errors.Join(err, openErr)
+	}
+	s.file = file
+	return err
+}
+
+// Close closes the store file
+func (s *VocabularyStore) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// openStore opens the store at path, failing the test on error, and closes it at the end of the test
+func openStore(t *testing.T, path string) *VocabularyStore {
+	t.Helper()
+	store, err := OpenVocabularyStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+func storeKeys(store *VocabularyStore) []string {
+	keys := make([]string, 0)
+	for _, entry := range store.Find(entities.VocabularyQuery{}) {
+		keys = append(keys, entry.Key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestVocabularyStoreReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocabulary.jsonl")
+	store := openStore(t, path)
+	chien := entities.Word{Language: languages.French, Term: "chien", Morphology: entities.ParseMorphology("n")}
+	chat := entities.Word{Language: languages.French, Term: "chat", Morphology: entities.ParseMorphology("n")}
+	for _, word := range []entities.Word{chien, chat} {
+		if err := store.SaveWord(word); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.SetNoteIDs(chien.Key(), []int64{7}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.AddAudio(chien.Key(), entities.Pronunciation{Path: "data/audio/chien.mp3"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(chat.Key()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := openStore(t, path)
+	if keys := storeKeys(replayed); !slices.Equal(keys, []string{chien.Key()}) {
+		t.Fatalf("keys = %q, want %q", keys, chien.Key())
+	}
+	entry, _ := replayed.Get(chien.Key())
+	if !slices.Equal(entry.NoteIDs, []int64{7}) || len(entry.Audio) != 1 || entry.Audio[0].Path != "data/audio/chien.mp3" {
+		t.Errorf("entry = %+v, want note 7 and its recording", entry)
+	}
+
+	if err := replayed.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("%d lines once compacted, want the header and one record", lines)
+	}
+}
+
+func TestVocabularyStoreMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocabulary.jsonl")
+	// A file of JSONLinesRepository, its first word mentions the schema without being a header
+	words := `{"language":"en","term":"schema","morphology":{"pos":"n"},"notes":"{\"schema\": 1}"}
+{"language":"fr","term":"chien","morphology":{"pos":"n"}}
+`
+	if err := os.WriteFile(path, []byte(words), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := openStore(t, path)
+	want := []string{"en|schema|n", "fr|chien|n"}
+	if keys := storeKeys(store); !slices.Equal(keys, want) {
+		t.Fatalf("keys = %q, want %q", keys, want)
+	}
+	if entry, _ := store.Get("fr|chien|n"); entry.AddedAt.IsZero() {
+		t.Error("migrated entry without the date it was added")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header, _, _ := strings.Cut(string(data), "\n"); header != `{"schema":1}` {
+		t.Errorf("header = %s, want the file rewritten in the current schema", header)
+	}
+}
+
+func TestVocabularyStoreHalfWrittenLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocabulary.jsonl")
+	content := `{"schema":1}
+{"key":"fr|chien|n","entry":{"key":"fr|chien|n","word":{"language":"fr","term":"chien","morphology":{"pos":"n"}}}}
+{"key":"fr|chat|n","entry":{"key":"fr|chat`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := openStore(t, path)
+	if keys := storeKeys(store); !slices.Equal(keys, []string{"fr|chien|n"}) {
+		t.Fatalf("keys = %q, want the complete record only", keys)
+	}
+	// The rewrite dropped the half-written line, so records appended now are read back
+	if err := store.SaveWord(entities.Word{Language: languages.French, Term: "chat", Morphology: entities.ParseMorphology("n")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if keys := storeKeys(openStore(t, path)); !slices.Equal(keys, []string{"fr|chat|n", "fr|chien|n"}) {
+		t.Errorf("keys = %q once reopened", keys)
+	}
+}
+
+func TestVocabularyStoreCorruptLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vocabulary.jsonl")
+	content := `{"schema":1}
+{"key":"fr|chat`
+	if err := os.WriteFile(path, []byte(content+"\n"+`{"key":"fr|chien|n"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenVocabularyStore(path); err == nil {
+		t.Error("no error for a corrupt line followed by records")
+	}
+}
+
+func TestVocabularyStoreSaveWordEnrichments(t *testing.T) {
+	store := openStore(t, filepath.Join(t.TempDir(), "vocabulary.jsonl"))
+	word := entities.Word{Language: languages.French, Term: "chien", Morphology: entities.ParseMorphology("n")}
+	word.Transcription = "ʃjɛ̃"
+	word.SetSource(entities.FieldTranscription, "CAMBRIDGE")
+	word.Translations = entities.Translations{languages.Russian: {"собака"}}
+	word.SetSource(entities.TranslationsField(languages.Russian), "REVERSO")
+	if err := store.SaveWord(word); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := store.Get(word.Key())
+
+	// Only the translations change, the transcription keeps the date it was fetched
+	word.Translations = entities.Translations{languages.Russian: {"собака", "пёс"}}
+	if err := store.SaveWord(word); err != nil {
+		t.Fatal(err)
+	}
+	second, _ := store.Get(word.Key())
+	if !second.Enrichments["CAMBRIDGE"].FetchedAt.Equal(first.Enrichments["CAMBRIDGE"].FetchedAt) {
+		t.Error("unchanged transcription stamped again")
+	}
+	if !second.Enrichments["REVERSO"].FetchedAt.After(first.Enrichments["REVERSO"].FetchedAt) {
+		t.Error("changed translations not stamped again")
+	}
+
+	// A source the word no longer names is removed
+	word.Transcription = ""
+	delete(word.Sources, entities.FieldTranscription)
+	if err := store.SaveWord(word); err != nil {
+		t.Fatal(err)
+	}
+	third, _ := store.Get(word.Key())
+	if _, ok := third.Enrichments["CAMBRIDGE"]; ok {
+		t.Errorf("enrichments = %+v, want CAMBRIDGE removed", third.Enrichments)
+	}
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"gopkg.in/yaml.v3"
+)
+
+// WordRepository stores enriched words in a file, so they can be reviewed, edited and versioned before going to Anki
+type WordRepository interface {
+	Save(filePath string, words []entities.Word) error
+	Load(filePath string) ([]entities.Word, error)
+}
+
+// NewWordRepository returns the repository of the file format given by the extension of the path:
+// .csv and .tsv, .jsonl and .ndjson, .yaml and .yml
+func NewWordRepository(filePath string) (WordRepository, error) {
+	switch ext := strings.ToLower(filepath.Ext(filePath)); ext {
+	case ".csv":
+		return NewCSVRepository(), nil
+	case ".tsv":
+		return NewCSVRepositoryWithOptions(CSVOptions{Delimiter: '\t'}), nil
+	case ".jsonl", ".ndjson":
+		return NewJSONLinesRepository(), nil
+	case ".yaml", ".yml":
+		return NewYAMLRepository(), nil
+	default:
+		return nil, fmt.Errorf("unknown word file format %q", ext)
+	}
+}
+
+// writeFile writes the file through a temporary one renamed once complete, a failed save keeps the previous version
+func writeFile(filePath string, write func(w io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	// CreateTemp makes the file private, the file replaced was not
+	err = file.Chmod(0644)
+	buffered := bufio.NewWriter(file)
+	if err == nil {
+		err = write(buffered)
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), filePath)
+}
+
+// JSONLinesRepository stores one word per line, every field included
+type JSONLinesRepository struct{}
+
+func NewJSONLinesRepository() *JSONLinesRepository {
+	return &JSONLinesRepository{}
+}
+
+func (r *JSONLinesRepository) Save(filePath string, words []entities.Word) error {
+	return writeFile(filePath, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		for _, word := range words {
+			if err := encoder.Encode(word); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *JSONLinesRepository) Load(filePath string) ([]entities.Word, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words := make([]entities.Word, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var word entities.Word
+		if err := json.Unmarshal(scanner.Bytes(), &word); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filePath, line, err)
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
+
+// YAMLRepository stores the words as a YAML list, the easiest format to edit by hand
+type YAMLRepository struct{}
+
+func NewYAMLRepository() *YAMLRepository {
+	return &YAMLRepository{}
+}
+
+func (r *YAMLRepository) Save(filePath string, words []entities.Word) error {
+	return writeFile(filePath, func(w io.Writer) error {
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(words); err != nil {
+			return err
+		}
+		return encoder.Close()
+	})
+}
+
+func (r *YAMLRepository) Load(filePath string) ([]entities.Word, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words := make([]entities.Word, 0)
+	if err := yaml.NewDecoder(file).Decode(&words); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return words, nil
+}
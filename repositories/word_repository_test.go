@@ -0,0 +1,112 @@
+package repositories
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// roundTripWords returns words filling every column of the CSV files, with examples holding their separators
+func roundTripWords() []entities.Word {
+	chien := entities.Word{
+		ID:            "fr|chien|n",
+		Language:      languages.French,
+		Term:          "chien",
+		Transcription: "ʃjɛ̃",
+		Translations:  entities.Translations{languages.Russian: {"собака", "пёс"}},
+		Plural:        "chiens",
+		Examples: []entities.ExamplePair{
+			{Source: "a => b c", Target: "t"},
+			{Source: "chien | loup", Target: "собака | волк"},
+			{Source: `un \ chien`},
+		},
+		Tags:  []string{"animals", "a1"},
+		Deck:  "Francais_mots",
+		Notes: "faux ami; voir chienne",
+		Sources: map[string]string{
+			entities.FieldTranscription:                   entities.SourceFile,
+			entities.TranslationsField(languages.Russian): entities.SourceFile,
+		},
+	}
+	chien.Morphology = entities.ParseMorphology("n")
+	chien.SetMorphology(entities.Morphology{Gender: entities.GenderMasculine})
+
+	// No concept, alt nor example: empty cells between filled ones
+	dog := entities.Word{
+		ID:         "en|dog|n",
+		Concept:    "12",
+		Language:   languages.English,
+		Term:       "dog",
+		Morphology: entities.ParseMorphology("n"),
+		Deck:       "English_words",
+	}
+	return []entities.Word{chien, dog}
+}
+
+// withoutEmpty sets the empty collections of the word to nil, the formats do not tell them apart
+func withoutEmpty(word entities.Word) entities.Word {
+	if len(word.Translations) == 0 {
+		word.Translations = nil
+	}
+	if len(word.Examples) == 0 {
+		word.Examples = nil
+	}
+	if len(word.Tags) == 0 {
+		word.Tags = nil
+	}
+	if len(word.Sources) == 0 {
+		word.Sources = nil
+	}
+	return word
+}
+
+func TestWordRepositoryRoundTrip(t *testing.T) {
+	for _, ext := range []string{".csv", ".tsv", ".jsonl", ".ndjson", ".yaml", ".yml"} {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "words"+ext)
+			repo, err := NewWordRepository(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			words := roundTripWords()
+			if err := repo.Save(path, words); err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := repo.Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded) != len(words) {
+				t.Fatalf("%d words loaded, want %d", len(loaded), len(words))
+			}
+			for i := range words {
+				if !reflect.DeepEqual(withoutEmpty(loaded[i]), withoutEmpty(words[i])) {
+					t.Errorf("word %d\n got %+v\nwant %+v", i, loaded[i], words[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseExamples(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []entities.ExamplePair
+	}{
+		{value: "le chat dort => the cat sleeps", want: []entities.ExamplePair{{Source: "le chat dort", Target: "the cat sleeps"}}},
+		{value: "un => one|deux", want: []entities.ExamplePair{{Source: "un", Target: "one"}, {Source: "deux"}}},
+		{value: `a \=> b c => t`, want: []entities.ExamplePair{{Source: "a => b c", Target: "t"}}},
+		{value: `x \| y => z`, want: []entities.ExamplePair{{Source: "x | y", Target: "z"}}},
+		{value: "a => b => c", want: []entities.ExamplePair{{Source: "a", Target: "b => c"}}},
+		{value: "", want: []entities.ExamplePair{}},
+	}
+	for _, test := range tests {
+		if got := parseExamples(test.value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseExamples(%q) = %+v, want %+v", test.value, got, test.want)
+		}
+	}
+}
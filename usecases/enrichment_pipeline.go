@@ -0,0 +1,216 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/entities"
+)
+
+// ErrSkipWord is returned by a stage to stop processing a word without counting it as failed
+var ErrSkipWord = errors.New("word skipped")
+
+// EnrichmentStage is a step of the pipeline run on every word, in the order the stages are given. A stage returning
+// an error wrapping ErrPartial did its work, the word goes on and the error is kept in its result
+type EnrichmentStage struct {
+	Name   string
+	Source string // stages sharing a source share its concurrency cap, empty for none
+	Run    func(ctx context.Context, word *entities.Word) error
+}
+
+// PipelineConfig configures EnrichmentPipeline
+type PipelineConfig struct {
+	Workers      int                             // words processed at once, at least 1
+	SourceLimits map[string]int                  // maximum concurrent stage runs per source
+	OnProgress   func(progress PipelineProgress) // called after every word, from one goroutine at a time
+}
+
+// PipelineProgress is a snapshot of a running pipeline
+type PipelineProgress struct {
+	Total   int
+	Done    int
+	Failed  int
+	Skipped int
+	Elapsed time.Duration
+	ETA     time.Duration
+}
+
+// PipelineResult is the outcome of one word
+type PipelineResult struct {
+	Index   int // position of the word in the input
+	Word    entities.Word
+	Stage   string // stage that failed or skipped the word, empty when every stage ran
+	Err     error
+	Skipped bool
+	// Warnings are the errors of stages that still gave a result, e.g. a source of a fallback chain failing
+	// before another answered
+	Warnings []error
+}
+
+// PipelineReport summarizes a pipeline run
+type PipelineReport struct {
+	PipelineProgress
+	Failures       []PipelineResult
+	Warned         int                      // words done or failed with warnings
+	StageDurations map[string]time.Duration // time spent in each stage, summed over words
+}
+
+// String formats the report for logs
+func (r PipelineReport) String() string {
+	return fmt.Sprintf("%d words: %d done, %d failed, %d skipped, %d with warnings in %s",
+		r.Total, r.Done, r.Failed, r.Skipped, r.Warned, r.Elapsed.Round(time.Millisecond))
+}
+
+// EnrichmentPipeline runs enrichment stages over words with a bounded worker pool
+type EnrichmentPipeline struct {
+	stages     []EnrichmentStage
+	config     PipelineConfig
+	semaphores map[string]chan struct{}
+}
+
+// NewEnrichmentPipeline creates a new EnrichmentPipeline
+func NewEnrichmentPipeline(config PipelineConfig, stages ...EnrichmentStage) *EnrichmentPipeline {
+	if config.Workers < 1 {
+		config.Workers = 1
+	}
+	semaphores := make(map[string]chan struct{}, len(config.SourceLimits))
+	for source, limit := range config.SourceLimits {
+		if limit > 0 {
+			semaphores[source] = make(chan struct{}, limit)
+		}
+	}
+	return &EnrichmentPipeline{stages: stages, config: config, semaphores: semaphores}
+}
+
+// Run enriches the words and calls emit with each result in input order. Cancelling ctx stops handing out words;
+// the words not started are not emitted
+func (p *EnrichmentPipeline) Run(ctx context.Context, words []entities.Word, emit func(result PipelineResult)) PipelineReport {
+	start := time.Now()
+	report := PipelineReport{
+		PipelineProgress: PipelineProgress{Total: len(words)},
+		StageDurations:   make(map[string]time.Duration),
+	}
+
+	jobs := make(chan int)
+	results := make(chan PipelineResult)
+	durations := make(chan map[string]time.Duration, p.config.Workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < p.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			spent := make(map[string]time.Duration)
+			for index := range jobs {
+				results <- p.process(ctx, index, words[index], spent)
+			}
+			durations <- spent
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for index := range words {
+			select {
+			case <-ctx.Done():
+				return
+			case jobs <- index:
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+		close(durations)
+	}()
+
+	// Results arrive in completion order and are held until every earlier word is emitted
+	pending := make(map[int]PipelineResult)
+	next := 0
+	for result := range results {
+		switch {
+		case result.Skipped:
+			report.Skipped++
+		case result.Err != nil:
+			report.Failed++
+			report.Failures = append(report.Failures, result)
+		default:
+			report.Done++
+		}
+		if len(result.Warnings) > 0 {
+			report.Warned++
+		}
+
+		report.Elapsed = time.Since(start)
+		finished := report.Done + report.Failed + report.Skipped
+		report.ETA = report.Elapsed / time.Duration(finished) * time.Duration(report.Total-finished)
+		if p.config.OnProgress != nil {
+			p.config.OnProgress(report.PipelineProgress)
+		}
+
+		pending[result.Index] = result
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			if emit != nil {
+				emit(r)
+			}
+			next++
+		}
+	}
+
+	for spent := range durations {
+		for stage, d := range spent {
+			report.StageDurations[stage] += d
+		}
+	}
+	report.Elapsed = time.Since(start)
+	report.ETA = 0
+
+	return report
+}
+
+// process runs every stage on one word, stopping at the first failing or skipping stage. Partial errors are kept
+// as warnings
+func (p *EnrichmentPipeline) process(ctx context.Context, index int, word entities.Word, spent map[string]time.Duration) PipelineResult {
+	result := PipelineResult{Index: index}
+	for _, stage := range p.stages {
+		if err := ctx.Err(); err != nil {
+			result.Stage, result.Err = stage.Name, err
+			break
+		}
+
+		semaphore, limited := p.semaphores[stage.Source]
+		if limited {
+			semaphore <- struct{}{}
+		}
+		started := time.Now()
+		err := stage.Run(ctx, &word)
+		spent[stage.Name] += time.Since(started)
+		if limited {
+			<-semaphore
+		}
+
+		if errors.Is(err, ErrSkipWord) {
+			result.Stage, result.Skipped = stage.Name, true
+			break
+		}
+		if errors.Is(err, ErrPartial) {
+			result.Warnings = append(result.Warnings, fmt.Errorf("%s: %w", stage.Name, err))
+			continue
+		}
+		if err != nil {
+			result.Stage, result.Err = stage.Name, fmt.Errorf("%s: %w", stage.Name, err)
+			break
+		}
+	}
+	result.Word = word
+	return result
+}
@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+)
+
+func TestEnrichmentPipelineWarnings(t *testing.T) {
+	sourceDown := errors.New("larousse: 503")
+	stages := []EnrichmentStage{
+		{Name: "translations", Run: func(_ context.Context, word *entities.Word) error {
+			word.Notes = "translated"
+			if word.Term == "chien" {
+				// Reverso answered after Larousse failed
+				return fmt.Errorf("%s: %w: %w", OperationTranslations, ErrPartial, sourceDown)
+			}
+			return nil
+		}},
+		{Name: "conjugation", Run: func(_ context.Context, word *entities.Word) error {
+			if word.Term == "chat" {
+				return sourceDown
+			}
+			return nil
+		}},
+	}
+	words := []entities.Word{{Term: "chien"}, {Term: "chat"}, {Term: "cheval"}}
+
+	results := make([]PipelineResult, 0, len(words))
+	report := NewEnrichmentPipeline(PipelineConfig{Workers: 2}, stages...).Run(context.Background(), words, func(result PipelineResult) {
+		results = append(results, result)
+	})
+
+	if report.Done != 2 || report.Failed != 1 || report.Warned != 1 {
+		t.Errorf("report = %s, want 2 done, 1 failed, 1 with warnings", report)
+	}
+	chien := results[0]
+	if chien.Err != nil || chien.Word.Notes != "translated" {
+		t.Errorf("chien = %+v, want the word enriched despite the failed source", chien)
+	}
+	if len(chien.Warnings) != 1 || !errors.Is(chien.Warnings[0], sourceDown) {
+		t.Errorf("chien warnings = %v, want the failed source", chien.Warnings)
+	}
+	if chat := results[1]; !errors.Is(chat.Err, sourceDown) || chat.Stage != "conjugation" || len(chat.Warnings) != 0 {
+		t.Errorf("chat = %+v, want failed by conjugation without warnings", chat)
+	}
+}
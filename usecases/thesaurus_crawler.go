@@ -0,0 +1,167 @@
+package usecases
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// SynonymsFetcher searches synonyms, the Reverso client implements it
+type SynonymsFetcher interface {
+	SynonymsWithOptions(text string, language *languages.Language, opts entities.SynonymOptions) (*entities.SynonymsResponse, error)
+}
+
+// ThesaurusCrawlerConfig bounds the breadth-first expansion of the crawler
+type ThesaurusCrawlerConfig struct {
+	MaxDepth         int     // how many hops from a seed the graph reaches
+	MaxNodes         int     // how many words the graph may hold, 0 means no limit
+	MinRelevance     float64 // synonyms below this relevance are not followed
+	SamePartOfSpeech bool    // follow only synonyms sharing a part of speech with the word they come from
+	Options          entities.SynonymOptions
+}
+
+// DefaultThesaurusCrawlerConfig returns a config small enough to crawl interactively
+func DefaultThesaurusCrawlerConfig() ThesaurusCrawlerConfig {
+	return ThesaurusCrawlerConfig{
+		MaxDepth:         2,
+		MaxNodes:         200,
+		SamePartOfSpeech: true,
+		Options:          entities.DefaultSynonymOptions(),
+	}
+}
+
+// ThesaurusCrawler builds a graph of the semantic neighborhood of seed words
+type ThesaurusCrawler struct {
+	fetcher SynonymsFetcher
+	config  ThesaurusCrawlerConfig
+}
+
+// NewThesaurusCrawler creates a new ThesaurusCrawler
+func NewThesaurusCrawler(fetcher SynonymsFetcher, config ThesaurusCrawlerConfig) *ThesaurusCrawler {
+	return &ThesaurusCrawler{fetcher: fetcher, config: config}
+}
+
+// Crawl expands the seeds breadth-first. On a failed search it returns the graph built so far along with the error
+func (c *ThesaurusCrawler) Crawl(language *languages.Language, seeds ...string) (*ThesaurusGraph, error) {
+	if len(seeds) == 0 {
+		return nil, errors.New("at least one seed is required")
+	}
+
+	graph := newThesaurusGraph(string(language.Code))
+	queue := make([]int, 0, len(seeds))
+	for _, seed := range seeds {
+		if id, added := c.addNode(graph, seed, nil, 0); added {
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		node := graph.Nodes[id]
+		if node.Depth >= c.config.MaxDepth {
+			continue
+		}
+
+		synonyms, err := c.fetcher.SynonymsWithOptions(node.Word, language, c.config.Options)
+		if err != nil {
+			return graph, err
+		}
+
+		for _, group := range synonymGroups(synonyms) {
+			partsOfSpeech := group.pos.PartsOfSpeech()
+			switch {
+			case len(partsOfSpeech) == 0:
+				// The constraint cannot be checked on synonyms of an undescribed part of speech, they are followed
+				// and take the part of speech of the word they come from
+				partsOfSpeech = node.PartsOfSpeech
+			case c.config.SamePartOfSpeech:
+				// A word of unknown part of speech, such as a seed, takes the first part of speech Reverso ranks for it
+				if len(node.PartsOfSpeech) == 0 {
+					graph.Nodes[id].PartsOfSpeech = partsOfSpeech
+					node = graph.Nodes[id]
+				}
+				if !intersects(node.PartsOfSpeech, partsOfSpeech) {
+					continue
+				}
+			}
+
+			for _, synonym := range group.clusters {
+				if synonym.Relevance < c.config.MinRelevance {
+					continue
+				}
+				to, added := c.addNode(graph, synonym.Word, partsOfSpeech, node.Depth+1)
+				if to < 0 {
+					continue
+				}
+				graph.addEdge(id, to, synonym.Relevance, synonym.Cluster)
+				if added && node.Depth+1 < c.config.MaxDepth {
+					queue = append(queue, to)
+				}
+			}
+		}
+	}
+
+	return graph, nil
+}
+
+// synonymGroup is a list of synonyms sharing a part of speech
+type synonymGroup struct {
+	pos      entities.Pos
+	clusters []entities.Cluster
+}
+
+// synonymGroups returns the synonyms of the response by result. The clusters Reverso merged into a result are
+// synonyms of the word too, under their own part of speech when they have one
+func synonymGroups(synonyms *entities.SynonymsResponse) []synonymGroup {
+	groups := make([]synonymGroup, 0, len(synonyms.Results))
+	for _, result := range synonyms.Results {
+		groups = append(groups, synonymGroup{pos: result.Pos, clusters: result.Cluster})
+		if result.Merged == nil || len(result.Merged.Cluster) == 0 {
+			continue
+		}
+		pos := result.Merged.Pos
+		if len(pos.Desc) == 0 {
+			pos = result.Pos
+		}
+		groups = append(groups, synonymGroup{pos: pos, clusters: result.Merged.Cluster})
+	}
+	return groups
+}
+
+// addNode returns the ID of the word, adding it unless the graph is full. The ID is -1 when the word could not be added
+func (c *ThesaurusCrawler) addNode(graph *ThesaurusGraph, word string, partsOfSpeech []string, depth int) (int, bool) {
+	key := strings.ToLower(strings.TrimSpace(word))
+	if key == "" {
+		return -1, false
+	}
+	if id, ok := graph.index[key]; ok {
+		return id, false
+	}
+	if c.config.MaxNodes > 0 && len(graph.Nodes) >= c.config.MaxNodes {
+		return -1, false
+	}
+
+	id := len(graph.Nodes)
+	graph.index[key] = id
+	graph.Nodes = append(graph.Nodes, ThesaurusNode{
+		ID:            id,
+		Word:          strings.TrimSpace(word),
+		PartsOfSpeech: partsOfSpeech,
+		Depth:         depth,
+	})
+	return id, true
+}
+
+func intersects(a, b []string) bool {
+	for _, v := range a {
+		if slices.Contains(b, v) {
+			return true
+		}
+	}
+	return false
+}
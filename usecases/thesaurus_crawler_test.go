@@ -0,0 +1,224 @@
+package usecases
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// fakeSynonyms answers the searches from a map of responses by word and records the words searched
+type fakeSynonyms struct {
+	responses map[string]*entities.SynonymsResponse
+	searched  []string
+}
+
+func (f *fakeSynonyms) SynonymsWithOptions(text string, _ *languages.Language, _ entities.SynonymOptions) (*entities.SynonymsResponse, error) {
+	f.searched = append(f.searched, text)
+	if res, ok := f.responses[text]; ok {
+		return res, nil
+	}
+	return nil, errors.New("no response for " + text)
+}
+
+// synonymsResult returns a result of the part of speech described by desc, with the words at the relevance
+func synonymsResult(desc string, relevance float64, words ...string) entities.SynonymResult {
+	result := entities.SynonymResult{Pos: entities.Pos{Desc: []string{desc}}}
+	for _, word := range words {
+		result.Cluster = append(result.Cluster, entities.Cluster{Word: word, Relevance: relevance})
+	}
+	return result
+}
+
+func synonymsResponse(results ...entities.SynonymResult) *entities.SynonymsResponse {
+	return &entities.SynonymsResponse{Results: results}
+}
+
+// thesaurusResponses is a small French thesaurus: chien leads to toutou and cabot, which lead back to it
+func thesaurusResponses() map[string]*entities.SynonymsResponse {
+	return map[string]*entities.SynonymsResponse{
+		"chien":    synonymsResponse(synonymsResult("nom", 80, "toutou", "cabot"), synonymsResult("verbe", 50, "chienner")),
+		"toutou":   synonymsResponse(synonymsResult("nom", 70, "Chien", "clébard")),
+		"cabot":    synonymsResponse(synonymsResult("nom", 90, "chien", "cabotin")),
+		"chienner": synonymsResponse(),
+	}
+}
+
+func TestThesaurusCrawler(t *testing.T) {
+	// The merged cluster comes without a description, its synonyms are those of the result it was merged into
+	merged := synonymsResponse(synonymsResult("nom", 60, "maison"))
+	merged.Results[0].Merged = &entities.Merged{Cluster: []entities.Cluster{{Word: "demeure", Relevance: 40}}}
+
+	// logis comes from an entry Reverso did not describe, it is followed and takes the part of speech of its results
+	undescribed := map[string]*entities.SynonymsResponse{
+		"habitation": synonymsResponse(synonymsResult("", 60, "logis")),
+		"logis":      synonymsResponse(synonymsResult("nom", 60, "gîte")),
+	}
+
+	tests := []struct {
+		name      string
+		responses map[string]*entities.SynonymsResponse
+		seed      string
+		config    ThesaurusCrawlerConfig
+		nodes     []string
+		searched  []string
+		edges     int
+	}{
+		{
+			name:      "depth limit",
+			responses: thesaurusResponses(),
+			seed:      "chien",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 1},
+			nodes:     []string{"chien", "toutou", "cabot", "chienner"},
+			searched:  []string{"chien"},
+			edges:     3,
+		},
+		{
+			// Chien found again from toutou is the seed, its edges with toutou and cabot are kept once
+			name:      "duplicates",
+			responses: thesaurusResponses(),
+			seed:      "chien",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 2},
+			nodes:     []string{"chien", "toutou", "cabot", "chienner", "clébard", "cabotin"},
+			searched:  []string{"chien", "toutou", "cabot", "chienner"},
+			edges:     5,
+		},
+		{
+			name:      "node limit",
+			responses: thesaurusResponses(),
+			seed:      "chien",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 2, MaxNodes: 2},
+			nodes:     []string{"chien", "toutou"},
+			searched:  []string{"chien", "toutou"},
+			edges:     1,
+		},
+		{
+			name:      "relevance threshold",
+			responses: thesaurusResponses(),
+			seed:      "chien",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 2, MinRelevance: 75},
+			nodes:     []string{"chien", "toutou", "cabot", "cabotin"},
+			searched:  []string{"chien", "toutou", "cabot"},
+			edges:     3,
+		},
+		{
+			name:      "same part of speech",
+			responses: thesaurusResponses(),
+			seed:      "chien",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 1, SamePartOfSpeech: true},
+			nodes:     []string{"chien", "toutou", "cabot"},
+			searched:  []string{"chien"},
+			edges:     2,
+		},
+		{
+			name:      "merged clusters",
+			responses: map[string]*entities.SynonymsResponse{"foyer": merged},
+			seed:      "foyer",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 1, SamePartOfSpeech: true},
+			nodes:     []string{"foyer", "maison", "demeure"},
+			searched:  []string{"foyer"},
+			edges:     2,
+		},
+		{
+			name:      "word of unknown part of speech",
+			responses: undescribed,
+			seed:      "habitation",
+			config:    ThesaurusCrawlerConfig{MaxDepth: 2, SamePartOfSpeech: true},
+			nodes:     []string{"habitation", "logis", "gîte"},
+			searched:  []string{"habitation", "logis"},
+			edges:     2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fetcher := &fakeSynonyms{responses: test.responses}
+			graph, err := NewThesaurusCrawler(fetcher, test.config).Crawl(languages.MustGet(languages.French), test.seed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes := make([]string, 0, len(graph.Nodes))
+			for _, node := range graph.Nodes {
+				nodes = append(nodes, node.Word)
+			}
+			if !slices.Equal(nodes, test.nodes) {
+				t.Errorf("nodes = %q, want %q", nodes, test.nodes)
+			}
+			if !slices.Equal(fetcher.searched, test.searched) {
+				t.Errorf("searched = %q, want %q", fetcher.searched, test.searched)
+			}
+			if len(graph.Edges) != test.edges {
+				t.Errorf("%d edges, want %d: %+v", len(graph.Edges), test.edges, graph.Edges)
+			}
+		})
+	}
+}
+
+func TestThesaurusCrawlerKeepsHighestWeight(t *testing.T) {
+	fetcher := &fakeSynonyms{responses: thesaurusResponses()}
+	graph, err := NewThesaurusCrawler(fetcher, ThesaurusCrawlerConfig{MaxDepth: 2}).Crawl(languages.MustGet(languages.French), "chien")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// chien -- cabot is seen at 80 from chien and at 90 from cabot
+	for _, edge := range graph.Edges {
+		if graph.Nodes[edge.From].Word == "chien" && graph.Nodes[edge.To].Word == "cabot" && edge.Weight != 90 {
+			t.Errorf("chien -- cabot weight = %v, want 90", edge.Weight)
+		}
+	}
+}
+
+func TestThesaurusGraphExports(t *testing.T) {
+	graph := newThesaurusGraph("fr")
+	graph.Nodes = []ThesaurusNode{{ID: 0, Word: "chien", PartsOfSpeech: []string{"n"}}, {ID: 1, Word: `"toutou"`, Depth: 1}, {ID: 2, Word: "cabot", Depth: 1}}
+	graph.addEdge(0, 1, 40, 1)
+	graph.addEdge(0, 2, 80, 1)
+
+	tests := []struct {
+		name  string
+		write func(*ThesaurusGraph, *bytes.Buffer) error
+		want  []string
+	}{
+		{
+			name:  "dot",
+			write: func(g *ThesaurusGraph, b *bytes.Buffer) error { return g.WriteDOT(b) },
+			want:  []string{"graph thesaurus {\n", `n1 [label="\"toutou\""];`, `n0 -- n1 [label="40", penwidth=3.00];`, `n0 -- n2 [label="80", penwidth=5.00];`},
+		},
+		{
+			name:  "graphml",
+			write: func(g *ThesaurusGraph, b *bytes.Buffer) error { return g.WriteGraphML(b) },
+			want:  []string{`<node id="n1">`, `<data key="word">&#34;toutou&#34;</data>`, `<data key="pos">n</data>`, `<edge source="n0" target="n2">`, `<data key="weight">80</data>`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buffer bytes.Buffer
+			if err := test.write(graph, &buffer); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(buffer.String(), want) {
+					t.Errorf("output lacks %s:\n%s", want, buffer.String())
+				}
+			}
+		})
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var buffer bytes.Buffer
+		if err := graph.WriteJSON(&buffer); err != nil {
+			t.Fatal(err)
+		}
+		var decoded ThesaurusGraph
+		if err := json.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if decoded.Language != "fr" || len(decoded.Nodes) != 3 || len(decoded.Edges) != 2 || decoded.Edges[1].Weight != 80 {
+			t.Errorf("decoded graph = %+v", decoded)
+		}
+	})
+}
@@ -0,0 +1,174 @@
+package usecases
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ThesaurusNode is a word of the graph
+type ThesaurusNode struct {
+	ID            int      `json:"id"`
+	Word          string   `json:"word"`
+	PartsOfSpeech []string `json:"parts_of_speech"`
+	Depth         int      `json:"depth"`
+}
+
+// ThesaurusEdge links two synonyms, Weight is the Reverso relevance of the link
+type ThesaurusEdge struct {
+	From    int     `json:"from"`
+	To      int     `json:"to"`
+	Weight  float64 `json:"weight"`
+	Cluster int64   `json:"cluster"`
+}
+
+// ThesaurusGraph is an undirected graph of synonyms built by ThesaurusCrawler
+type ThesaurusGraph struct {
+	Language string          `json:"language"`
+	Nodes    []ThesaurusNode `json:"nodes"`
+	Edges    []ThesaurusEdge `json:"edges"`
+
+	index map[string]int
+	edges map[[2]int]int
+}
+
+func newThesaurusGraph(language string) *ThesaurusGraph {
+	return &ThesaurusGraph{
+		Language: language,
+		Nodes:    make([]ThesaurusNode, 0),
+		Edges:    make([]ThesaurusEdge, 0),
+		index:    make(map[string]int),
+		edges:    make(map[[2]int]int),
+	}
+}
+
+// addEdge links two nodes once, keeping the highest weight seen from either side
+func (g *ThesaurusGraph) addEdge(from, to int, weight float64, cluster int64) {
+	if from == to {
+		return
+	}
+	key := [2]int{min(from, to), max(from, to)}
+	if i, ok := g.edges[key]; ok {
+		if weight > g.Edges[i].Weight {
+			g.Edges[i].Weight = weight
+		}
+		return
+	}
+	g.edges[key] = len(g.Edges)
+	g.Edges = append(g.Edges, ThesaurusEdge{From: from, To: to, Weight: weight, Cluster: cluster})
+}
+
+// WriteDOT writes the graph in the Graphviz DOT format
+func (g *ThesaurusGraph) WriteDOT(w io.Writer) error {
+	var builder strings.Builder
+	builder.WriteString("graph thesaurus {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(&builder, "\tn%d [label=%s];\n", node.ID, strconv.Quote(node.Word))
+	}
+	// DOT weights are integers, the weight is shown as the label and as a pen width of 1 to 5
+	maxWeight := 0.0
+	for _, edge := range g.Edges {
+		maxWeight = max(maxWeight, edge.Weight)
+	}
+	for _, edge := range g.Edges {
+		penwidth := 1.0
+		if maxWeight > 0 {
+			penwidth += 4 * edge.Weight / maxWeight
+		}
+		fmt.Fprintf(&builder, "\tn%d -- n%d [label=%s, penwidth=%s];\n", edge.From, edge.To,
+			strconv.Quote(strconv.FormatFloat(edge.Weight, 'f', -1, 64)), strconv.FormatFloat(penwidth, 'f', 2, 64))
+	}
+	builder.WriteString("}\n")
+
+	_, err := io.WriteString(w, builder.String())
+	return err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes the graph in the GraphML format
+func (g *ThesaurusGraph) WriteGraphML(w io.Writer) error {
+	doc := graphML{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "word", For: "node", AttrName: "word", AttrType: "string"},
+			{ID: "pos", For: "node", AttrName: "pos", AttrType: "string"},
+			{ID: "depth", For: "node", AttrName: "depth", AttrType: "int"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+		},
+		Graph: graphMLGraph{ID: "thesaurus", EdgeDefault: "undirected"},
+	}
+	for _, node := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: fmt.Sprintf("n%d", node.ID),
+			Data: []graphMLData{
+				{Key: "word", Value: node.Word},
+				{Key: "pos", Value: strings.Join(node.PartsOfSpeech, ",")},
+				{Key: "depth", Value: strconv.Itoa(node.Depth)},
+			},
+		})
+	}
+	for _, edge := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: fmt.Sprintf("n%d", edge.From),
+			Target: fmt.Sprintf("n%d", edge.To),
+			Data:   []graphMLData{{Key: "weight", Value: strconv.FormatFloat(edge.Weight, 'f', -1, 64)}},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJSON writes the graph as a JSON document of nodes and edges
+func (g *ThesaurusGraph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g)
+}
@@ -0,0 +1,137 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/marycka9/go-reverso-api/common"
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"github.com/marycka9/go-reverso-api/repositories"
+)
+
+// RankedTranslation is a translation candidate merged across sources
+type RankedTranslation struct {
+	Translation string                   // spelling returned by the heaviest source
+	Score       float64                  // sum of the weights of the sources that returned it
+	Sources     []TranslationServiceType // sources that returned it, heaviest first
+	rank        float64                  // average position in the source lists, breaks ties
+}
+
+// SetSourceWeight sets how much a source's vote counts when merging translations. Sources default to 1
+func (s *TranslationService) SetSourceWeight(service TranslationServiceType, weight float64) {
+	if s.weights == nil {
+		s.weights = make(map[TranslationServiceType]float64)
+	}
+	s.weights[service] = weight
+}
+
+func (s *TranslationService) weight(service TranslationServiceType) float64 {
+	if weight, ok := s.weights[service]; ok {
+		return weight
+	}
+	return 1
+}
+
+// GetTranslationsConsensus queries every source able to translate concurrently, merges candidates that differ only by
+// case, accents or articles and ranks them by weighted votes. The ranked spellings replace the word's translations
+// into dstLang. The errors of the sources that failed while others answered are returned wrapped in ErrPartial
+func (s *TranslationService) GetTranslationsConsensus(word *entities.Word, srcLang, dstLang *languages.Language) ([]RankedTranslation, error) {
+	if word.Term == "" {
+		return nil, errors.New("term cannot be empty")
+	}
+
+	type answer struct {
+		service      TranslationServiceType
+		translations []string
+		err          error
+	}
+
+	answers := make([]answer, 0, len(s.sources))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for service, source := range s.sources {
+		translator, ok := source.(repositories.Translator)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release := s.acquire(service)
+			translations, err := translator.FetchTranslations(word.Term, string(word.Morphology.PartOfSpeech), srcLang, dstLang)
+			release()
+			mu.Lock()
+			answers = append(answers, answer{service: service, translations: translations, err: err})
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// Heaviest sources vote first so that their spelling is kept
+	sort.Slice(answers, func(i, j int) bool {
+		if wi, wj := s.weight(answers[i].service), s.weight(answers[j].service); wi != wj {
+			return wi > wj
+		}
+		return answers[i].service < answers[j].service
+	})
+
+	ranked := make([]RankedTranslation, 0)
+	index := make(map[string]int)
+	var errs []error
+	for _, a := range answers {
+		if a.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", a.service, a.err))
+			continue
+		}
+		for position, translation := range a.translations {
+			key := common.NormalizeTerm(translation, dstLang.Code)
+			if key == "" {
+				continue
+			}
+			i, ok := index[key]
+			if !ok {
+				i = len(ranked)
+				index[key] = i
+				ranked = append(ranked, RankedTranslation{Translation: strings.TrimSpace(translation)})
+			}
+			if slices.Contains(ranked[i].Sources, a.service) {
+				continue
+			}
+			votes := float64(len(ranked[i].Sources))
+			ranked[i].rank = (ranked[i].rank*votes + float64(position)) / (votes + 1)
+			ranked[i].Score += s.weight(a.service)
+			ranked[i].Sources = append(ranked[i].Sources, a.service)
+		}
+	}
+
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("%s: %w", OperationTranslations, errors.Join(append([]error{ErrNoResult}, errs...)...))
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].rank < ranked[j].rank
+	})
+
+	if word.Translations == nil {
+		word.Translations = make(entities.Translations)
+	}
+	translations := make([]string, 0, len(ranked))
+	for _, r := range ranked {
+		translations = append(translations, r.Translation)
+	}
+	word.Translations[dstLang.Code] = translations
+	word.SetSource(entities.TranslationsField(dstLang.Code), "consensus")
+
+	if len(errs) > 0 {
+		return ranked, fmt.Errorf("%s: %w: %w", OperationTranslations, ErrPartial, errors.Join(errs...))
+	}
+	return ranked, nil
+}
@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"github.com/marycka9/go-reverso-api/repositories"
+)
+
+// Operation names a TranslationService operation that can fall back across sources
+type Operation string
+
+// Operations with a fallback chain
+const (
+	OperationTranslations   Operation = "translations"
+	OperationTranscription  Operation = "transcription"
+	OperationAdditionalData Operation = "additional data"
+	OperationConjugation    Operation = "conjugation"
+	OperationExamples       Operation = "examples"
+	OperationPronunciation  Operation = "pronunciation"
+)
+
+// ErrNoResult is returned when no source of a fallback chain returned a result and none failed: each one lacked
+// the capability, did not handle the language or found nothing
+var ErrNoResult = errors.New("no source returned a result")
+
+// ErrPartial wraps the errors of sources that failed when another one still returned a result: the result is
+// usable and the errors are only worth reporting
+var ErrPartial = errors.New("partial result")
+
+// DefaultFallbackChains returns the order sources are tried in for each operation
+func DefaultFallbackChains() map[Operation][]TranslationServiceType {
+	return map[Operation][]TranslationServiceType{
+		OperationTranslations:   {REVERSO, CAMBRIDGE, LAROUSSE},
+		OperationTranscription:  {CAMBRIDGE, LAROUSSE},
+		OperationAdditionalData: {LAROUSSE, CAMBRIDGE},
+		OperationConjugation:    {REVERSO, LAROUSSE},
+		OperationExamples:       {REVERSO, CAMBRIDGE, LAROUSSE},
+		OperationPronunciation:  {REVERSO, CAMBRIDGE},
+	}
+}
+
+// SetFallbackChain replaces the sources tried, in order, for the operation
+func (s *TranslationService) SetFallbackChain(operation Operation, chain ...TranslationServiceType) {
+	s.chains[operation] = chain
+}
+
+// FallbackChain returns the sources tried, in order, for the operation
+func (s *TranslationService) FallbackChain(operation Operation) []TranslationServiceType {
+	return s.chains[operation]
+}
+
+// fallback calls try with each source of the chain until one returns a non-empty result. When none does, it
+// returns the errors of the sources that failed, or ErrNoResult. When one does after others failed, their errors
+// are returned wrapped in ErrPartial along with it
+func (s *TranslationService) fallback(operation Operation, try func(service TranslationServiceType) (bool, error)) (TranslationServiceType, error) {
+	var errs []error
+	for _, service := range s.chains[operation] {
+		found, err := try(service)
+		if errors.Is(err, repositories.ErrUnsupported) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if found && len(errs) > 0 {
+			return service, fmt.Errorf("%s: %w: %w", operation, ErrPartial, errors.Join(errs...))
+		}
+		if found {
+			return service, nil
+		}
+	}
+	if len(errs) == 0 {
+		return 0, fmt.Errorf("%s: %w", operation, ErrNoResult)
+	}
+	return 0, fmt.Errorf("%s: %w", operation, errors.Join(errs...))
+}
+
+// GetTranslationsWithFallback fetches translations from the first source of the chain that adds any to the ones
+// the word already has, e.g. from its file
+func (s *TranslationService) GetTranslationsWithFallback(word *entities.Word, srcLang, dstLang *languages.Language) error {
+	_, err := s.fallback(OperationTranslations, func(service TranslationServiceType) (bool, error) {
+		before := len(word.Translations[dstLang.Code])
+		if err := s.GetTranslations(service, word, srcLang, dstLang); err != nil {
+			return false, err
+		}
+		return len(word.Translations[dstLang.Code]) > before, nil
+	})
+	return err
+}
+
+// GetTranscriptionsWithFallback fetches the transcription from the first source of the chain that has one
+func (s *TranslationService) GetTranscriptionsWithFallback(word *entities.Word, srcLang, dstLang *languages.Language) error {
+	_, err := s.fallback(OperationTranscription, func(service TranslationServiceType) (bool, error) {
+		if err := s.GetTranscriptions(service, word, srcLang, dstLang); err != nil {
+			return false, err
+		}
+		return word.Transcription != "", nil
+	})
+	return err
+}
+
+// GetAdditionalDataWithFallback completes the word from the first source of the chain that changes it
+func (s *TranslationService) GetAdditionalDataWithFallback(word *entities.Word) error {
+	_, err := s.fallback(OperationAdditionalData, func(service TranslationServiceType) (bool, error) {
+		before := *word
+		if err := s.GetAdditionalData(service, word); err != nil {
+			return false, err
+		}
+		changed := word.Term != before.Term || word.TermAlt != before.TermAlt || !word.Morphology.Equal(before.Morphology) ||
+			word.Transcription != before.Transcription || word.Plural != before.Plural
+		return changed, nil
+	})
+	return err
+}
+
+// GetConjugationWithFallback fetches the conjugation from the first source of the chain that has one
+// and returns the source it came from
+func (s *TranslationService) GetConjugationWithFallback(term string, lang *languages.Language) (*entities.FrenchVerbConjugation, TranslationServiceType, error) {
+	var res *entities.FrenchVerbConjugation
+	service, err := s.fallback(OperationConjugation, func(service TranslationServiceType) (bool, error) {
+		conjugation, err := s.GetConjugation(service, term, lang)
+		if err != nil {
+			return false, err
+		}
+		res = conjugation
+		return conjugation != nil && len(conjugation.Indicatif) > 0, nil
+	})
+	return res, service, err
+}
+
+// GetExamplesWithFallback fetches examples from the first source of the chain that has any
+// and returns the source they came from
+func (s *TranslationService) GetExamplesWithFallback(term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, TranslationServiceType, error) {
+	var res []entities.ExamplePair
+	service, err := s.fallback(OperationExamples, func(service TranslationServiceType) (bool, error) {
+		examples, err := s.GetExamples(service, term, srcLang, dstLang)
+		if err != nil {
+			return false, err
+		}
+		res = examples
+		return len(examples) > 0, nil
+	})
+	return res, service, err
+}
+
+// GetPronunciationWithFallback saves a pronunciation from the first source of the chain that has one
+// and returns the path of the audio file and the source it came from
+func (s *TranslationService) GetPronunciationWithFallback(term string, lang *languages.Language, dir string) (string, TranslationServiceType, error) {
+	var res string
+	service, err := s.fallback(OperationPronunciation, func(service TranslationServiceType) (bool, error) {
+		path, err := s.GetPronunciation(service, term, lang, dir)
+		if err != nil {
+			return false, err
+		}
+		res = path
+		return path != "", nil
+	})
+	return res, service, err
+}
@@ -12,8 +12,8 @@ import (
 type TranslationServiceType int
 
 // Translation Service Type lists the available translation services. These constants are used to select the appropriate
-// fetcher in the Translation Service structure. When adding a new translation service, define a new constant in this
-// block and add the corresponding fetcher to the fetchers field of the Translation Service structure.
+// source in the Translation Service structure. When adding a new translation service, define a new constant in this
+// block and register the corresponding source with NewTranslationService.
 const (
 	REVERSO   TranslationServiceType = iota // Reverso Context
 	CAMBRIDGE                               // Dictionary Cambridge
@@ -34,14 +34,62 @@ func (t TranslationServiceType) String() string {
 	}
 }
 
+// ErrSourceNotFound is returned when no source is registered for a service type
+var ErrSourceNotFound = errors.New("source not found")
+
 // TranslationService manages fetching translations from various sources
 type TranslationService struct {
-	fetchers map[TranslationServiceType]repositories.TranslationFetcher
+	sources map[TranslationServiceType]repositories.Source
+	chains  map[Operation][]TranslationServiceType
+	weights map[TranslationServiceType]float64
+	limits  map[TranslationServiceType]chan struct{}
+}
+
+// NewTranslationService creates a new TranslationService with the default fallback chains.
+// What each source can do is discovered by type assertion, a source refusing a language fails with
+// repositories.ErrUnsupported like one lacking the capability
+func NewTranslationService(sources map[TranslationServiceType]repositories.Source) *TranslationService {
+	return &TranslationService{sources: sources, chains: DefaultFallbackChains(), limits: make(map[TranslationServiceType]chan struct{})}
+}
+
+// SetConcurrencyLimit caps the calls made at once to the source of the service, whichever operation or fallback
+// chain makes them. Set the limits before using the service concurrently
+func (s *TranslationService) SetConcurrencyLimit(service TranslationServiceType, limit int) {
+	if limit < 1 {
+		delete(s.limits, service)
+		return
+	}
+	s.limits[service] = make(chan struct{}, limit)
+}
+
+// acquire waits for a free slot of the service and returns the function releasing it
+func (s *TranslationService) acquire(service TranslationServiceType) func() {
+	semaphore, ok := s.limits[service]
+	if !ok {
+		return func() {}
+	}
+	semaphore <- struct{}{}
+	return func() { <-semaphore }
+}
+
+// capability returns the source of the service as T, or ErrUnsupported when the source lacks the capability
+func capability[T any](s *TranslationService, service TranslationServiceType, name string) (T, error) {
+	var zero T
+	source, ok := s.sources[service]
+	if !ok {
+		return zero, fmt.Errorf("%s service: %w", service, ErrSourceNotFound)
+	}
+	res, ok := source.(T)
+	if !ok {
+		return zero, fmt.Errorf("%s service: %s: %w", service, name, repositories.ErrUnsupported)
+	}
+	return res, nil
 }
 
-// NewTranslationService creates a new TranslationService
-func NewTranslationService(fetchers map[TranslationServiceType]repositories.TranslationFetcher) *TranslationService {
-	return &TranslationService{fetchers: fetchers}
+// Supports reports whether the source of the service implements the capability interface T
+func Supports[T any](s *TranslationService, service TranslationServiceType) bool {
+	_, err := capability[T](s, service, "")
+	return err == nil
 }
 
 // GetTranslations fetches translations from all available sources
@@ -50,51 +98,93 @@ func (s *TranslationService) GetTranslations(service TranslationServiceType, wor
 		return errors.New("term cannot be empty")
 	}
 
-	if fetcher, ok := s.fetchers[service]; ok {
-		// If there are new sources of transfers, then change 0 to the corresponding identifier.
-		translations, err := fetcher.FetchTranslations(word.Term, word.PartOfSpeech, srcLang, dstLang)
-		if err != nil {
-			return err
-		}
-		word.Translations[entities.Language(dstLang.Code)] = append(word.Translations[entities.Language(dstLang.Code)], translations...)
-		return nil
+	translator, err := capability[repositories.Translator](s, service, "translations")
+	if err != nil {
+		return err
 	}
-
-	return errors.New("translation service not found")
+	release := s.acquire(service)
+	translations, err := translator.FetchTranslations(word.Term, string(word.Morphology.PartOfSpeech), srcLang, dstLang)
+	release()
+	if err != nil {
+		return repositories.WrapUnsupported(err)
+	}
+	if word.Translations == nil {
+		word.Translations = make(entities.Translations)
+	}
+	word.Translations[dstLang.Code] = append(word.Translations[dstLang.Code], translations...)
+	if len(translations) > 0 {
+		word.SetSource(entities.TranslationsField(dstLang.Code), service.String())
+	}
+	return nil
 }
 
-func (s *TranslationService) GetTranscriptions(service TranslationServiceType, word *entities.Word, srcLang, dstLang entities.Language) error {
+func (s *TranslationService) GetTranscriptions(service TranslationServiceType, word *entities.Word, srcLang, dstLang *languages.Language) error {
 	if word.Term == "" {
 		return errors.New("term cannot be empty")
 	}
-	if fetcher, ok := s.fetchers[service]; ok {
-		transcription, err := fetcher.FetchTranscription(word.Term, srcLang, dstLang)
-		if err != nil {
-			return err
-		}
-		word.Transcription = transcription
-		return nil
+	transcriber, err := capability[repositories.Transcriber](s, service, "transcriptions")
+	if err != nil {
+		return err
 	}
-	return errors.New("transcriptions service not found")
+	release := s.acquire(service)
+	transcription, err := transcriber.FetchTranscription(word.Term, srcLang, dstLang)
+	release()
+	if err != nil {
+		return repositories.WrapUnsupported(err)
+	}
+	word.Transcription = transcription
+	if transcription != "" {
+		word.SetSource(entities.FieldTranscription, service.String())
+	}
+	return nil
 }
 
 func (s *TranslationService) GetAdditionalData(service TranslationServiceType, word *entities.Word) error {
-	if fetcher, ok := s.fetchers[service]; ok {
-		if err := fetcher.FetchAdditionalData(word); err != nil {
-			return err
-		}
-		return nil
+	enricher, err := capability[repositories.Enricher](s, service, "additional data")
+	if err != nil {
+		return err
+	}
+	release := s.acquire(service)
+	err = enricher.FetchAdditionalData(word)
+	release()
+	if err != nil {
+		return repositories.WrapUnsupported(err)
+	}
+	word.SetSource(entities.FieldAdditionalData, service.String())
+	return nil
+}
+
+func (s *TranslationService) GetConjugation(service TranslationServiceType, term string, lang *languages.Language) (*entities.FrenchVerbConjugation, error) {
+	conjugator, err := capability[repositories.Conjugator](s, service, "conjugation")
+	if err != nil {
+		return nil, err
+	}
+	release := s.acquire(service)
+	conjugation, err := conjugator.FetchConjugation(term, lang)
+	release()
+	return conjugation, repositories.WrapUnsupported(err)
+}
+
+// GetExamples fetches example sentences using the term along with their translations
+func (s *TranslationService) GetExamples(service TranslationServiceType, term string, srcLang, dstLang *languages.Language) ([]entities.ExamplePair, error) {
+	provider, err := capability[repositories.ExampleProvider](s, service, "examples")
+	if err != nil {
+		return nil, err
 	}
-	return fmt.Errorf("AdditionalData service: %s not found", service.String())
+	release := s.acquire(service)
+	examples, err := provider.FetchExamples(term, srcLang, dstLang)
+	release()
+	return examples, repositories.WrapUnsupported(err)
 }
 
-func (s *TranslationService) GetConjugation(service TranslationServiceType, term string, lang entities.Language) (*entities.FrenchVerbConjugation, error) {
-	if fetcher, ok := s.fetchers[service]; ok {
-		verbConj, err := fetcher.FetchConjugation(term, lang)
-		if err != nil {
-			return nil, err
-		}
-		return verbConj, nil
+// GetPronunciation saves a pronunciation of the term under dir and returns the path of the audio file
+func (s *TranslationService) GetPronunciation(service TranslationServiceType, term string, lang *languages.Language, dir string) (string, error) {
+	pronouncer, err := capability[repositories.Pronouncer](s, service, "pronunciation")
+	if err != nil {
+		return "", err
 	}
-	return nil, fmt.Errorf("Conjugation service: %s not found", service.String())
+	release := s.acquire(service)
+	path, err := pronouncer.FetchPronunciation(term, lang, dir)
+	release()
+	return path, repositories.WrapUnsupported(err)
 }
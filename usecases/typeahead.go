@@ -0,0 +1,231 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/common"
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// TypeaheadSource provides completions over the network, the Reverso client implements it
+type TypeaheadSource interface {
+	AutoComplete(text string, language *languages.Language) (*entities.AutoCompleteResponse, error)
+	SuggestWithOptions(text string, srcLang, dstLang *languages.Language, opts entities.SuggestOptions) (*entities.SuggestResponse, error)
+}
+
+// TypeaheadConfig configures Typeahead
+type TypeaheadConfig struct {
+	Target            *languages.Language // target language of the suggest requests
+	Debounce          time.Duration       // delay before a prefix missing from the index is fetched
+	MaxResults        int64               // results asked of Suggest, fewer exact suggestions mean Suggest had no more
+	AutoCompleteLimit int64               // most completions AutoComplete returns, fewer mean it had no more. 0 if unknown
+}
+
+// typeaheadWeight is the weight of the first word of a fetch, the others get a share of it by rank so the words of
+// fetches of different sizes compare
+const typeaheadWeight = 1000
+
+// Typeahead completes prefixes from AutoComplete and Suggest, caching what they return in a prefix index per language.
+// Keystrokes extending an exhaustive prefix are served from the index without a round-trip
+type Typeahead struct {
+	source TypeaheadSource
+	config TypeaheadConfig
+
+	mu         sync.Mutex
+	tries      map[languages.Code]*common.PrefixTrie
+	fetched    map[string]bool // language and prefix of every fetch both sources answered
+	exhaustive map[string]bool // language and prefix of the fetches that returned every completion
+	calls      map[string]*typeaheadCall
+}
+
+// typeaheadCall is a fetch in flight shared by concurrent identical queries
+type typeaheadCall struct {
+	done chan struct{}
+	err  error
+}
+
+// NewTypeahead creates a new Typeahead
+func NewTypeahead(source TypeaheadSource, config TypeaheadConfig) *Typeahead {
+	return &Typeahead{
+		source:     source,
+		config:     config,
+		tries:      make(map[languages.Code]*common.PrefixTrie),
+		fetched:    make(map[string]bool),
+		exhaustive: make(map[string]bool),
+		calls:      make(map[string]*typeaheadCall),
+	}
+}
+
+// Complete returns at most n completions of the prefix in the language. Cancelling ctx, e.g. on the next keystroke,
+// abandons the query while a fetch already started still fills the index for later queries
+func (t *Typeahead) Complete(ctx context.Context, prefix string, lang *languages.Language, n int) ([]string, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, nil
+	}
+
+	if t.cached(prefix, lang) {
+		return t.lookup(prefix, lang, n), nil
+	}
+
+	if t.config.Debounce > 0 {
+		timer := time.NewTimer(t.config.Debounce)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	call := t.fetch(prefix, lang)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	return t.lookup(prefix, lang, n), nil
+}
+
+func typeaheadKey(prefix string, lang *languages.Language) string {
+	return string(lang.Code) + "\x00" + strings.ToLower(prefix)
+}
+
+// cached reports whether the prefix was fetched or extends an exhaustive prefix
+func (t *Typeahead) cached(prefix string, lang *languages.Language) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.fetched[typeaheadKey(prefix, lang)] {
+		return true
+	}
+	for i := range prefix {
+		if i > 0 && t.exhaustive[typeaheadKey(prefix[:i], lang)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Typeahead) lookup(prefix string, lang *languages.Language, n int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	trie, ok := t.tries[lang.Code]
+	if !ok {
+		return nil
+	}
+	return trie.Complete(prefix, n)
+}
+
+// fetch starts a fetch of the prefix or joins the one in flight
+func (t *Typeahead) fetch(prefix string, lang *languages.Language) *typeaheadCall {
+	key := typeaheadKey(prefix, lang)
+
+	t.mu.Lock()
+	if call, ok := t.calls[key]; ok {
+		t.mu.Unlock()
+		return call
+	}
+	call := &typeaheadCall{done: make(chan struct{})}
+	t.calls[key] = call
+	t.mu.Unlock()
+
+	go func() {
+		words, complete, exhaustive, err := t.query(prefix, lang)
+
+		t.mu.Lock()
+		if err == nil {
+			trie, ok := t.tries[lang.Code]
+			if !ok {
+				trie = common.NewPrefixTrie()
+				t.tries[lang.Code] = trie
+			}
+			for i, word := range words {
+				trie.Insert(word, int64(typeaheadWeight*(len(words)-i)/len(words)))
+			}
+			// A fetch one source failed is served this time and fetched again on the next query
+			if complete {
+				t.fetched[key] = true
+			}
+			if complete && exhaustive {
+				t.exhaustive[key] = true
+			}
+		}
+		call.err = err
+		delete(t.calls, key)
+		t.mu.Unlock()
+
+		close(call.done)
+	}()
+
+	return call
+}
+
+// query asks both sources concurrently and merges their answers, AutoComplete first. It fails only if both sources fail,
+// complete reports whether none did
+func (t *Typeahead) query(prefix string, lang *languages.Language) (words []string, complete, exhaustive bool, err error) {
+	var wg sync.WaitGroup
+	var autoComplete *entities.AutoCompleteResponse
+	var suggest *entities.SuggestResponse
+	var autoCompleteErr, suggestErr error
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		autoComplete, autoCompleteErr = t.source.AutoComplete(prefix, lang)
+	}()
+	if t.config.Target != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			opts := entities.SuggestOptions{MaxResults: t.config.MaxResults}
+			suggest, suggestErr = t.source.SuggestWithOptions(prefix, lang, t.config.Target, opts)
+		}()
+	}
+	wg.Wait()
+
+	if autoCompleteErr != nil && (suggestErr != nil || t.config.Target == nil) {
+		return nil, false, false, errors.Join(autoCompleteErr, suggestErr)
+	}
+
+	words = make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(word string) {
+		key := strings.ToLower(word)
+		if !seen[key] && strings.HasPrefix(key, strings.ToLower(prefix)) {
+			seen[key] = true
+			words = append(words, word)
+		}
+	}
+
+	complete = autoCompleteErr == nil && suggestErr == nil
+	exhaustive = complete
+	if autoComplete != nil {
+		for _, word := range *autoComplete {
+			add(word)
+		}
+		exhaustive = exhaustive && t.config.AutoCompleteLimit > 0 && int64(len(*autoComplete)) < t.config.AutoCompleteLimit
+	}
+	if suggest != nil {
+		count := 0
+		for _, suggestion := range suggest.Ranked() {
+			if suggestion.Fuzziness == entities.SuggestionExact && suggestion.Lang == string(lang.Code) {
+				add(suggestion.Suggestion)
+				count++
+			}
+		}
+		exhaustive = exhaustive && t.config.MaxResults > 0 && int64(count) < t.config.MaxResults
+	}
+
+	return words, complete, exhaustive, nil
+}
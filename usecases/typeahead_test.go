@@ -0,0 +1,197 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+)
+
+// fakeTypeahead completes prefixes from a word list and counts the requests. Requests wait for gate when it is set
+type fakeTypeahead struct {
+	words      []string
+	suggestErr error
+	gate       chan struct{}
+
+	autoCompletes atomic.Int32
+	suggests      atomic.Int32
+}
+
+func (f *fakeTypeahead) matching(text string) []string {
+	res := make([]string, 0)
+	for _, word := range f.words {
+		if strings.HasPrefix(strings.ToLower(word), strings.ToLower(text)) {
+			res = append(res, word)
+		}
+	}
+	return res
+}
+
+func (f *fakeTypeahead) AutoComplete(text string, _ *languages.Language) (*entities.AutoCompleteResponse, error) {
+	f.autoCompletes.Add(1)
+	if f.gate != nil {
+		<-f.gate
+	}
+	res := entities.AutoCompleteResponse(f.matching(text))
+	return &res, nil
+}
+
+func (f *fakeTypeahead) SuggestWithOptions(text string, srcLang, _ *languages.Language, _ entities.SuggestOptions) (*entities.SuggestResponse, error) {
+	f.suggests.Add(1)
+	if f.gate != nil {
+		<-f.gate
+	}
+	if f.suggestErr != nil {
+		return nil, f.suggestErr
+	}
+	res := &entities.SuggestResponse{}
+	for _, word := range f.matching(text) {
+		res.Suggestions = append(res.Suggestions, entities.FuzzySuggestion{Lang: string(srcLang.Code), Suggestion: word})
+	}
+	return res, nil
+}
+
+var typeaheadWords = []string{"chat", "chaton", "chien", "chienne", "cheval"}
+
+func TestTypeaheadServesExhaustivePrefixes(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   TypeaheadConfig
+		fetches  int32
+		complete []string // completions of "chi"
+	}{
+		{
+			// "ch" returned fewer completions than the limits, "chi" is served from the index
+			name:     "exhaustive prefix",
+			config:   TypeaheadConfig{AutoCompleteLimit: 10, MaxResults: 10},
+			fetches:  1,
+			complete: []string{"chien", "chienne"},
+		},
+		{
+			name:     "truncated prefix",
+			config:   TypeaheadConfig{AutoCompleteLimit: 5, MaxResults: 10},
+			fetches:  2,
+			complete: []string{"chien", "chienne"},
+		},
+		{
+			name:     "unknown limit",
+			config:   TypeaheadConfig{MaxResults: 10},
+			fetches:  2,
+			complete: []string{"chien", "chienne"},
+		},
+	}
+	french := languages.MustGet(languages.French)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source := &fakeTypeahead{words: typeaheadWords}
+			test.config.Target = languages.MustGet(languages.English)
+			typeahead := NewTypeahead(source, test.config)
+
+			if _, err := typeahead.Complete(context.Background(), "ch", french, 0); err != nil {
+				t.Fatal(err)
+			}
+			got, err := typeahead.Complete(context.Background(), "chi", french, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, test.complete) {
+				t.Errorf("completions = %q, want %q", got, test.complete)
+			}
+			if n := source.autoCompletes.Load(); n != test.fetches {
+				t.Errorf("%d AutoComplete requests, want %d", n, test.fetches)
+			}
+			// A prefix already fetched is never fetched again
+			if _, err := typeahead.Complete(context.Background(), "CH", french, 0); err != nil {
+				t.Fatal(err)
+			}
+			if n := source.autoCompletes.Load(); n != test.fetches {
+				t.Errorf("%d AutoComplete requests after a fetched prefix, want %d", n, test.fetches)
+			}
+		})
+	}
+}
+
+func TestTypeaheadRefetchesAfterFailedSource(t *testing.T) {
+	source := &fakeTypeahead{words: typeaheadWords, suggestErr: errors.New("suggest is down")}
+	typeahead := NewTypeahead(source, TypeaheadConfig{Target: languages.MustGet(languages.English), AutoCompleteLimit: 10, MaxResults: 10})
+	french := languages.MustGet(languages.French)
+
+	for range 2 {
+		got, err := typeahead.Complete(context.Background(), "chat", french, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(got, []string{"chat", "chaton"}) {
+			t.Errorf("completions = %q, want AutoComplete's", got)
+		}
+	}
+	if n := source.autoCompletes.Load(); n != 2 {
+		t.Errorf("%d AutoComplete requests, want the prefix fetched again", n)
+	}
+}
+
+func TestTypeaheadCoalescesQueries(t *testing.T) {
+	source := &fakeTypeahead{words: typeaheadWords, gate: make(chan struct{})}
+	typeahead := NewTypeahead(source, TypeaheadConfig{Target: languages.MustGet(languages.English)})
+	french := languages.MustGet(languages.French)
+
+	var wg sync.WaitGroup
+	results := make([][]string, 8)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = typeahead.Complete(context.Background(), "che", french, 3)
+		}()
+	}
+	// Queries arriving once the fetch is done are served from the index, so the count is one either way
+	time.Sleep(20 * time.Millisecond)
+	close(source.gate)
+	wg.Wait()
+
+	for i := range results {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if !slices.Equal(results[i], []string{"cheval"}) {
+			t.Errorf("query %d = %q, want cheval", i, results[i])
+		}
+	}
+	if n, m := source.autoCompletes.Load(), source.suggests.Load(); n != 1 || m != 1 {
+		t.Errorf("%d AutoComplete and %d Suggest requests, want one of each", n, m)
+	}
+}
+
+func TestTypeaheadDebounce(t *testing.T) {
+	source := &fakeTypeahead{words: typeaheadWords}
+	typeahead := NewTypeahead(source, TypeaheadConfig{Debounce: time.Hour})
+	french := languages.MustGet(languages.French)
+
+	// The next keystroke cancels the query before the debounce delay ends, nothing is fetched
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := typeahead.Complete(ctx, "ch", french, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the deadline of the query", err)
+	}
+	if n := source.autoCompletes.Load(); n != 0 {
+		t.Errorf("%d AutoComplete requests during the debounce delay, want 0", n)
+	}
+
+	typeahead = NewTypeahead(source, TypeaheadConfig{Debounce: time.Millisecond})
+	got, err := typeahead.Complete(context.Background(), "chat", french, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []string{"chat", "chaton"}) || source.autoCompletes.Load() != 1 {
+		t.Errorf("completions = %q after %d requests, want the fetched ones", got, source.autoCompletes.Load())
+	}
+}
@@ -1,50 +1,226 @@
 package usecases
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/marycka9/go-reverso-api/common"
 	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
 )
 
-type WordTranslator struct {
-	posParser *common.PartOfSpeechParser
+// AmbiguousLink is a translation given by the file that names several words of the other language,
+// none of which could be told apart by the part of speech. The words are left unlinked
+type AmbiguousLink struct {
+	Word       entities.Word
+	Language   languages.Code
+	Candidates []entities.Word
+}
+
+// LinkReport tells how the words of the files were linked by TranslateWords
+type LinkReport struct {
+	// Linked is the number of words linked to a word of another language
+	Linked int
+	// Unlinked are the words linked to no word of another language
+	Unlinked  []entities.Word
+	Ambiguous []AmbiguousLink
 }
 
+// String returns a summary followed by one line per ambiguous link and unlinked word
+func (r LinkReport) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%d words linked, %d unlinked, %d ambiguous links", r.Linked, len(r.Unlinked), len(r.Ambiguous))
+	for _, link := range r.Ambiguous {
+		candidates := make([]string, 0, len(link.Candidates))
+		for _, candidate := range link.Candidates {
+			candidates = append(candidates, fmt.Sprintf("%s (%s)", candidate.Term, candidate.Morphology))
+		}
+		fmt.Fprintf(&builder, "\n [%s] %s: %s translation matches %s", link.Word.Language, link.Word.Term, link.Language, strings.Join(candidates, ", "))
+	}
+	for _, word := range r.Unlinked {
+		fmt.Fprintf(&builder, "\n [%s] %s (%s): unlinked", word.Language, word.Term, word.Morphology)
+	}
+	return builder.String()
+}
+
+type WordTranslator struct{}
+
 func NewWordTranslator() *WordTranslator {
-	return &WordTranslator{
-		posParser: common.GetPartOfSpeechParserInstance(),
+	return &WordTranslator{}
+}
+
+// linkGroups is a union-find of the indexes of the words, every group is a concept
+type linkGroups []int
+
+func (g linkGroups) find(i int) int {
+	for g[i] != i {
+		g[i] = g[g[i]]
+		i = g[i]
 	}
+	return i
 }
 
-// TranslateWords links words between languages and adds translations to the Word structure
-func (t *WordTranslator) TranslateWords(wordsByLanguage map[entities.Language][]entities.Word) []entities.Word {
-	translations := make([]entities.Word, 0)
-
-	// Indexing words by term and language for quick search
-	index := make(map[string]map[entities.Language]*entities.Word)
-	for lang, words := range wordsByLanguage {
-		for i := range words {
-			words[i].PartOfSpeech = t.posParser.Parse(words[i].PartOfSpeech)
-			if index[words[i].Term] == nil {
-				index[words[i].Term] = make(map[entities.Language]*entities.Word)
-			}
-			index[words[i].Term][lang] = &words[i]
+func (g linkGroups) union(i, j int) {
+	i, j = g.find(i), g.find(j)
+	// The first word of a group stays its root, so groups list their words in order
+	if j < i {
+		i, j = j, i
+	}
+	g[j] = i
+}
+
+// TranslateWords links the words across languages and adds the terms of the linked words to their translations.
+// Words sharing the concept column are all linked together. A translation column naming a word links the two words
+// and gives them a concept, unless one of them names several words of the other's language: a word with
+// translations of two meanings is linked to both but does not join them into one concept. A word of the same
+// spelling in another language is a homograph, not a translation. The words are returned by language code,
+// in file order, the words of a concept all with the concept column of its first word having one, or the key of
+// its first word
+func (t *WordTranslator) TranslateWords(wordsByLanguage map[languages.Code][]entities.Word) ([]entities.Word, LinkReport) {
+	codes := make([]languages.Code, 0, len(wordsByLanguage))
+	for code := range wordsByLanguage {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	words := make([]entities.Word, 0)
+	for _, code := range codes {
+		for _, word := range wordsByLanguage[code] {
+			word.Language = code
+			words = append(words, word)
+		}
+	}
+
+	report := LinkReport{}
+	groups := make(linkGroups, len(words))
+	for i := range groups {
+		groups[i] = i
+	}
+
+	// Words sharing a concept
+	concepts := make(map[string]int)
+	for i, word := range words {
+		if word.Concept == "" {
+			continue
+		}
+		if first, ok := concepts[word.Concept]; ok {
+			groups.union(first, i)
+		} else {
+			concepts[word.Concept] = i
 		}
 	}
 
-	// We are looking for a translation for each word
-	for lang, words := range wordsByLanguage {
-		for _, word := range words {
-			// Adding transfers
-			translationsMap := make(entities.Translations)
-			for targetLang, wordMap := range index[word.Term] {
-				if targetLang != lang {
-					translationsMap[targetLang] = append(translationsMap[targetLang], wordMap.Term) // Adding the full word
+	// Words named by the translations of the file, linked by pairs
+	pairs := make(map[int][]int)
+	link := func(i, j int) {
+		if !slices.Contains(pairs[i], j) {
+			pairs[i] = append(pairs[i], j)
+			pairs[j] = append(pairs[j], i)
+		}
+	}
+	terms := make(map[languages.Code]map[string][]int)
+	for i, word := range words {
+		if terms[word.Language] == nil {
+			terms[word.Language] = make(map[string][]int)
+		}
+		key := common.NormalizeTerm(word.Term, word.Language)
+		terms[word.Language][key] = append(terms[word.Language][key], i)
+	}
+	for i, word := range words {
+		for _, code := range codes {
+			if code == word.Language {
+				continue
+			}
+			for _, translation := range word.Translations[code] {
+				candidates := terms[code][common.NormalizeTerm(translation, code)]
+				if len(candidates) > 1 && word.Morphology.Known() {
+					candidates = slices.DeleteFunc(slices.Clone(candidates), func(j int) bool {
+						return words[j].Morphology.PartOfSpeech != word.Morphology.PartOfSpeech
+					})
+				}
+				switch len(candidates) {
+				case 0:
+				case 1:
+					link(i, candidates[0])
+				default:
+					link := AmbiguousLink{Word: word, Language: code}
+					for _, j := range candidates {
+						link.Candidates = append(link.Candidates, words[j])
+					}
+					report.Ambiguous = append(report.Ambiguous, link)
 				}
 			}
-			word.Translations = translationsMap
-			translations = append(translations, word)
 		}
 	}
 
-	return translations
+	// Pairs join the concept of the words, except the links of a word to several words of a language
+	branching := func(i, j int) bool {
+		count := 0
+		for _, k := range pairs[i] {
+			if words[k].Language == words[j].Language {
+				count++
+			}
+		}
+		return count > 1
+	}
+	for i, linked := range pairs {
+		for _, j := range linked {
+			if !branching(i, j) && !branching(j, i) {
+				groups.union(i, j)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range words {
+		root := groups.find(i)
+		members[root] = append(members[root], i)
+	}
+
+	conceptOf := make(map[int]string)
+	for root, group := range members {
+		if len(group) < 2 {
+			continue
+		}
+		concept := words[root].Key()
+		if first := slices.IndexFunc(group, func(i int) bool { return words[i].Concept != "" }); first >= 0 {
+			concept = words[group[first]].Concept
+		}
+		conceptOf[root] = concept
+	}
+
+	res := make([]entities.Word, 0, len(words))
+	for i, word := range words {
+		// Adding transfers, next to the translations the file gave
+		translations := make(entities.Translations)
+		for code, terms := range word.Translations {
+			translations[code] = slices.Clone(terms)
+		}
+		linked := false
+		for _, j := range append(slices.Clone(members[groups.find(i)]), pairs[i]...) {
+			other := words[j]
+			if other.Language == word.Language {
+				continue
+			}
+			linked = true
+			key := common.NormalizeTerm(other.Term, other.Language)
+			if !slices.ContainsFunc(translations[other.Language], func(term string) bool {
+				return common.NormalizeTerm(term, other.Language) == key
+			}) {
+				translations[other.Language] = append(translations[other.Language], other.Term)
+			}
+		}
+		if linked {
+			report.Linked++
+		} else {
+			report.Unlinked = append(report.Unlinked, word)
+		}
+		word.Translations = translations
+		if concept, ok := conceptOf[groups.find(i)]; ok {
+			word.Concept = concept
+		}
+		res = append(res, word)
+	}
+	return res, report
 }
@@ -0,0 +1,198 @@
+package usecases
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/marycka9/go-reverso-api/entities"
+	"github.com/marycka9/go-reverso-api/languages"
+	"github.com/marycka9/go-reverso-api/repositories"
+)
+
+func TestTranslateWords(t *testing.T) {
+	noun := entities.ParseMorphology("n")
+	tests := []struct {
+		name     string
+		words    map[languages.Code][]entities.Word
+		want     map[string]entities.Translations // by term
+		concepts map[string]string
+		linked   int
+	}{
+		{
+			// avocat translates into both English words, the Russian ones into one each: the meanings stay apart,
+			// avocat is linked to both without a concept
+			name: "translations of two meanings",
+			words: map[languages.Code][]entities.Word{
+				languages.French: {{Term: "avocat", Morphology: noun, Translations: entities.Translations{languages.English: {"lawyer", "avocado"}}}},
+				languages.English: {
+					{Term: "lawyer", Morphology: noun},
+					{Term: "avocado", Morphology: noun},
+				},
+				languages.Russian: {
+					{Term: "адвокат", Morphology: noun, Translations: entities.Translations{languages.English: {"lawyer"}}},
+					{Term: "авокадо", Morphology: noun, Translations: entities.Translations{languages.English: {"avocado"}}},
+				},
+			},
+			want: map[string]entities.Translations{
+				"avocat":  {languages.English: {"lawyer", "avocado"}},
+				"lawyer":  {languages.French: {"avocat"}, languages.Russian: {"адвокат"}},
+				"avocado": {languages.French: {"avocat"}, languages.Russian: {"авокадо"}},
+				"адвокат": {languages.English: {"lawyer"}},
+				"авокадо": {languages.English: {"avocado"}},
+			},
+			concepts: map[string]string{
+				"lawyer": "en|lawyer|n", "адвокат": "en|lawyer|n",
+				"avocado": "en|avocado|n", "авокадо": "en|avocado|n",
+			},
+			linked: 5,
+		},
+		{
+			// The words linked by their translations make one concept, dog and собака are linked through chien
+			name: "linked by translations",
+			words: map[languages.Code][]entities.Word{
+				languages.French:  {{Term: "chien", Morphology: noun, Translations: entities.Translations{languages.English: {"dog"}}}},
+				languages.English: {{Term: "dog", Morphology: noun}, {Term: "cat", Morphology: noun}},
+				languages.Russian: {{Term: "собака", Morphology: noun, Translations: entities.Translations{languages.French: {"chien"}}}},
+			},
+			want: map[string]entities.Translations{
+				"chien":  {languages.English: {"dog"}, languages.Russian: {"собака"}},
+				"dog":    {languages.French: {"chien"}, languages.Russian: {"собака"}},
+				"собака": {languages.French: {"chien"}, languages.English: {"dog"}},
+				"cat":    {},
+			},
+			concepts: map[string]string{"chien": "en|dog|n", "dog": "en|dog|n", "собака": "en|dog|n"},
+			linked:   3,
+		},
+		{
+			name: "shared concept",
+			words: map[languages.Code][]entities.Word{
+				languages.French: {{Term: "chien", Concept: "dog", Morphology: noun}, {Term: "chat", Morphology: noun}},
+				languages.Russian: {
+					{Term: "собака", Concept: "dog", Morphology: noun},
+					{Term: "пёс", Concept: "dog", Morphology: noun},
+				},
+			},
+			want: map[string]entities.Translations{
+				"chien":  {languages.Russian: {"собака", "пёс"}},
+				"собака": {languages.French: {"chien"}},
+				"пёс":    {languages.French: {"chien"}},
+				"chat":   {},
+			},
+			concepts: map[string]string{"chien": "dog", "собака": "dog", "пёс": "dog"},
+			linked:   3,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			words, report := NewWordTranslator().TranslateWords(test.words)
+			for _, word := range words {
+				if !maps.EqualFunc(word.Translations, test.want[word.Term], slices.Equal) {
+					t.Errorf("%s translations = %q, want %q", word.Term, word.Translations, test.want[word.Term])
+				}
+				if word.Concept != test.concepts[word.Term] {
+					t.Errorf("%s concept = %q, want %q", word.Term, word.Concept, test.concepts[word.Term])
+				}
+			}
+			if report.Linked != test.linked {
+				t.Errorf("%d words linked, want %d", report.Linked, test.linked)
+			}
+		})
+	}
+}
+
+// writeFiles writes the files of the contents by name in a temporary directory and returns their paths by name
+func writeFiles(t *testing.T, contents map[string]string) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	paths := make(map[string]string, len(contents))
+	for name, content := range contents {
+		paths[name] = filepath.Join(dir, name)
+		if err := os.WriteFile(paths[name], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// joinParallel links the words of the vocabulary files by language and writes them to a parallel file, as the
+// convert command does, then reads the parallel file back
+func joinParallel(t *testing.T, filePaths map[languages.Code]string, parallelPath string) (map[languages.Code][]entities.Word, LinkReport) {
+	t.Helper()
+	wordsByLanguage := make(map[languages.Code][]entities.Word)
+	for code, filePath := range filePaths {
+		words, _, err := repositories.NewCSVRepository().ReadWordsFromFile(filePath, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wordsByLanguage[code] = words
+	}
+	words, report := NewWordTranslator().TranslateWords(wordsByLanguage)
+	if err := repositories.NewParallelRepository().Save(parallelPath, words); err != nil {
+		t.Fatal(err)
+	}
+	joined, _, err := repositories.NewParallelRepository().ReadWordsFromFile(parallelPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return joined, report
+}
+
+// terms returns the terms of the words, and their concepts by term
+func terms(words []entities.Word) ([]string, map[string]string) {
+	res := make([]string, 0, len(words))
+	concepts := make(map[string]string, len(words))
+	for _, word := range words {
+		res = append(res, word.Term)
+		concepts[word.Term] = word.Concept
+	}
+	return res, concepts
+}
+
+func TestJoinParallelTranslations(t *testing.T) {
+	paths := writeFiles(t, map[string]string{
+		"french.csv":  "term;pos;translation_en\nchien;n;dog\nchat;n;cat\n",
+		"english.csv": "dog;n\n",
+	})
+	joined, _ := joinParallel(t, map[languages.Code]string{languages.French: paths["french.csv"], languages.English: paths["english.csv"]},
+		filepath.Join(filepath.Dir(paths["french.csv"]), "vocabulary.csv"))
+
+	// dog is a word of the English file and the translation of chien, it is written once in the row of chien
+	french, frenchConcepts := terms(joined[languages.French])
+	english, englishConcepts := terms(joined[languages.English])
+	if !slices.Equal(french, []string{"chien", "chat"}) || !slices.Equal(english, []string{"dog", "cat"}) {
+		t.Fatalf("words read back = %q and %q, want chien, chat and dog, cat", french, english)
+	}
+	if frenchConcepts["chien"] != englishConcepts["dog"] || frenchConcepts["chat"] != englishConcepts["cat"] || frenchConcepts["chien"] == frenchConcepts["chat"] {
+		t.Errorf("concepts = %q and %q, want one per row", frenchConcepts, englishConcepts)
+	}
+}
+
+func TestSplitJoinParallel(t *testing.T) {
+	paths := writeFiles(t, map[string]string{"vocabulary.csv": "pos;fr;en\nn;chien;dog\nn;chat;cat\n"})
+	wordsByLanguage, _, err := repositories.NewParallelRepository().ReadWordsFromFile(paths["vocabulary.csv"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Split into the files of the hand-written layout, as convert -split does without -full
+	dir := filepath.Dir(paths["vocabulary.csv"])
+	filePaths := make(map[languages.Code]string)
+	for code, words := range wordsByLanguage {
+		filePaths[code] = filepath.Join(dir, string(code)+".csv")
+		if err := repositories.NewCSVRepository().SaveLegacy(filePaths[code], words); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	joined, report := joinParallel(t, filePaths, filepath.Join(dir, "joined.csv"))
+	if report.Linked != 4 || len(report.Unlinked) != 0 {
+		t.Errorf("%s, want every word linked", report)
+	}
+	_, frenchConcepts := terms(joined[languages.French])
+	_, englishConcepts := terms(joined[languages.English])
+	if frenchConcepts["chien"] != englishConcepts["dog"] || frenchConcepts["chat"] != englishConcepts["cat"] || frenchConcepts["chien"] == frenchConcepts["chat"] {
+		t.Errorf("concepts = %q and %q, want the rows of the parallel file", frenchConcepts, englishConcepts)
+	}
+}
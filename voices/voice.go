@@ -1,5 +1,7 @@
 package voices
 
+import "github.com/marycka9/go-reverso-api/languages"
+
 const VoiceArabic = "Mehdi22k"
 
 const VoiceEnglishMale = "Ryan22k"
@@ -37,3 +39,28 @@ const VoiceHebrew = "he-IL-Asaf"
 const VoiceRomanian = "ro-RO-Andrei"
 
 const VoiceChinese = "Lulu22k"
+
+// voicesByLanguage holds the default voice of each language
+var voicesByLanguage = map[languages.Code]string{
+	languages.Arabic:     VoiceArabic,
+	languages.English:    VoiceEnglishFemale,
+	languages.French:     VoiceFrenchFemale,
+	languages.German:     VoiceGerman,
+	languages.Italian:    VoiceItalianFemale,
+	languages.Portuguese: VoicePortuguese,
+	languages.Spanish:    VoiceSpanishFemale,
+	languages.Dutch:      VoiceDutchName,
+	languages.Russian:    VoiceRussian,
+	languages.Polish:     VoicePolish,
+	languages.Japanese:   VoiceJapanese,
+	languages.Turkish:    VoiceTurkish,
+	languages.Hebrew:     VoiceHebrew,
+	languages.Romanian:   VoiceRomanian,
+	languages.Chinese:    VoiceChinese,
+}
+
+// ForLanguage returns the default voice of the language
+func ForLanguage(code languages.Code) (string, bool) {
+	voice, ok := voicesByLanguage[code]
+	return voice, ok
+}